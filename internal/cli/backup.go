@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"fmt"
+
+	"dockerdb/internal/databases"
+	"dockerdb/internal/docker"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	backupCmd.Flags().StringP("output", "o", "", "Dump file (default <name>-<timestamp>.<format>)")
+	backupCmd.Flags().BoolP("gzip", "z", false, "Compress the dump with gzip")
+	rootCmd.AddCommand(backupCmd)
+}
+
+var backupCmd = &cobra.Command{
+	Use:   "backup <name>",
+	Short: "Write a logical dump of a dockerdb container to a local file",
+	Long: `Write a logical dump of a dockerdb container to a local file, using the engine's
+native tool inside the container: pg_dump, mysqldump / mariadb-dump,
+mongodump --archive, or BGSAVE and a copy of the RDB file for Redis.
+
+The engine, image, tag and time of the dump are recorded in <file>.json.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		compress, _ := cmd.Flags().GetBool("gzip")
+
+		client, err := docker.NewDockerClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		fmt.Printf("Backing up %s...\n", args[0])
+		path, err := databases.Backup(cmd.Context(), client, args[0], output, compress)
+		if err != nil {
+			return fmt.Errorf("backing up %s: %w", args[0], err)
+		}
+		fmt.Printf("Backup written to %s (metadata in %s)\n", path, databases.MetadataPath(path))
+		return nil
+	},
+}
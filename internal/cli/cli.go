@@ -1,9 +1,9 @@
 package cli
 
 import (
-	"bufio"
 	"context"
 	"dockerdb/internal/databases"
+	"dockerdb/internal/version"
 	"fmt"
 	"os"
 	"strings"
@@ -14,22 +14,67 @@ import (
 var rootCmd = &cobra.Command{
 	Use:   "dockerdb [database-type]",
 	Short: "A command-line utility to set up Docker containers for various databases",
-	Long:  `dockerdb is a CLI tool that simplifies the setup of Docker containers for databases like MySQL, MariaDB, PostgreSQL, MongoDB, and Redis.`,
+	Long:  `dockerdb is a CLI tool that simplifies the setup of Docker containers for databases like MySQL, MariaDB, PostgreSQL, MongoDB, Redis, ClickHouse, CockroachDB, Cassandra, Elasticsearch and OpenSearch.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("Welcome to dockerdb! Please specify a database type.")
-		fmt.Println("Available database types: mysql, mariadb, postgres, mongodb, redis")
+		fmt.Println("Available database types: " + strings.Join(databases.Kinds(), ", "))
 		fmt.Println("Usage: dockerdb [database-type]")
 	},
+	Version:       version.Version,
+	SilenceErrors: true,
+	SilenceUsage:  true,
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
 func init() {
+	addSetupFlags(mysqlCmd, "mysql-db", "3306", "mysql_data")
+	addInitFlag(mysqlCmd)
+	addPasswordFlags(mysqlCmd, "DB user password")
+	mysqlCmd.Flags().String("root-password", "", "DB root password")
+	mysqlCmd.Flags().String("database", "mydb", "Database name")
+	mysqlCmd.Flags().String("user", "user", "DB user")
+	mysqlCmd.Flags().Int("replicas", 0, "Also start this many GTID replicas of the database")
+
+	addSetupFlags(mariadbCmd, "mariadb-db", "3306", "mariadb_data")
+	addInitFlag(mariadbCmd)
+	addPasswordFlags(mariadbCmd, "DB user password")
+	mariadbCmd.Flags().String("root-password", "", "DB root password")
+	mariadbCmd.Flags().String("database", "mydb", "Database name")
+	mariadbCmd.Flags().String("user", "user", "DB user")
+	mariadbCmd.Flags().Int("replicas", 0, "Also start this many GTID replicas of the database")
+
+	addSetupFlags(postgresCmd, "postgres-db", "5432", "postgres_data")
+	addInitFlag(postgresCmd)
+	addPasswordFlags(postgresCmd, "DB user password")
+	postgresCmd.Flags().String("database", "postgres", "Database name")
+	postgresCmd.Flags().String("user", "postgres", "DB user")
+	postgresCmd.Flags().Int("replicas", 0, "Also start this many streaming read replicas of the database")
+
+	addSetupFlags(mongodbCmd, "mongodb", "27017", "mongodb_data")
+	addInitFlag(mongodbCmd)
+	addPasswordFlags(mongodbCmd, "Admin password (with --auth)")
+	mongodbCmd.Flags().Bool("auth", false, "Enable authentication")
+	mongodbCmd.Flags().String("user", "admin", "Admin username (with --auth)")
+	mongodbCmd.Flags().Int("replica-set", 0, "Start a replica set with this many members instead of a standalone server")
+	mongodbCmd.Flags().String("replica-set-name", databases.DefaultReplicaSet, "Name of the replica set (with --replica-set)")
+
+	addSetupFlags(redisCmd, "redis", "6379", "redis_data")
+	addPasswordFlags(redisCmd, "Password (optional)")
+	redisCmd.Flags().String("maxmemory", "", "Memory limit of the dataset, e.g. 256mb")
+	redisCmd.Flags().String("eviction-policy", "", "Eviction policy once --maxmemory is reached, e.g. allkeys-lru")
+	redisCmd.Flags().String("persistence", "", "Persistence mode: rdb, aof, both or none (default: the image's, rdb)")
+	redisCmd.Flags().String("appendfsync", "", "How often the append-only file is synced: always, everysec or no")
+	redisCmd.Flags().StringSlice("acl-user", nil, "ACL user to create as name[:role] with a generated password (repeatable; Redis 6.2+)")
+	redisCmd.Flags().Int("replicas", 0, "Also start this many replicas of the server (default 2 with --sentinel)")
+	redisCmd.Flags().Bool("sentinel", false, "Watch the server and its replicas with three Sentinels that fail over automatically")
+	redisCmd.Flags().Bool("cluster", false, "Start a Redis Cluster of three masters and three replicas instead (Redis 7+)")
+
 	rootCmd.AddCommand(mysqlCmd)
 	rootCmd.AddCommand(mariadbCmd)
 	rootCmd.AddCommand(postgresCmd)
@@ -37,285 +82,247 @@ func init() {
 	rootCmd.AddCommand(redisCmd)
 }
 
-// promptForInput asks the user for input with the given prompt text
-func promptForInput(prompt string, defaultValue string) string {
-	reader := bufio.NewReader(os.Stdin)
-	if defaultValue != "" {
-		fmt.Printf("%s (%s): ", prompt, defaultValue)
-	} else {
-		fmt.Printf("%s: ", prompt)
-	}
-	input, _ := reader.ReadString('\n')
-	input = strings.TrimSpace(input)
-	if input == "" {
-		return defaultValue
+// runSetup provisions engine, prints its connection details and reports
+// any generated passwords
+func runSetup(ctx context.Context, in *inputs, engine databases.Engine) error {
+	if err := databases.Setup(ctx, engine); err != nil {
+		return fmt.Errorf("setting up %s container: %w", engine.DisplayName(), err)
 	}
-	return input
+	printConnectionInfo(engine)
+	return in.reportGenerated()
 }
 
-var mysqlCmd = &cobra.Command{
-    Use:   "mysql",
-    Short: "Set up a MySQL Docker container",
-    Run: func(cmd *cobra.Command, args []string) {
-        fmt.Println("Setting up MySQL Docker container...")
-
-        // Prompt for configuration
-        containerName := promptForInput("Container Name", "mysql-db")
-        imageTag := promptForInput("Image Tag (latest, 8.0, 5.7, etc)", "latest")
-        port := promptForInput("DB Port", "3306")
-        rootPassword := promptForInput("DB Root Password", "")
-        dbName := promptForInput("Database Name", "mydb")
-        user := promptForInput("DB User", "user")
-        userPassword := promptForInput("DB User Password", "")
-        volume := promptForInput("Data Volume", "mysql_data")
-        network := promptForInput("Docker Network (leave empty for no specific network)", "")
-
-        if rootPassword == "" {
-            fmt.Println("Error: Root password cannot be empty")
-            return
-        }
-
-        if userPassword == "" {
-            fmt.Println("Error: User password cannot be empty")
-            return
-        }
+// runTopology provisions a replica set or cluster and prints how to reach it
+func runTopology(cmd *cobra.Command, in *inputs, engine databases.Engine, provision func(context.Context, databases.Backend) (*databases.Topology, error)) error {
+	var topology *databases.Topology
+	err := withClient(cmd, func(ctx context.Context, backend databases.Backend) error {
+		var err error
+		topology, err = provision(ctx, backend)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("setting up %s containers: %w", engine.DisplayName(), err)
+	}
+	printTopology(engine, topology)
+	return in.reportGenerated()
+}
 
-        // Set up MySQL container
-        config := databases.MySQLConfig{
-            Name:         containerName,
-            Image:        "mysql:" + imageTag,
-            Port:         port,
-            RootPassword: rootPassword,
-            DatabaseName: dbName,
-            User:         user,
-            Password:     userPassword,
-            Volume:       volume,
-            Network:      network,
-        }
+// printTopology prints the members and connection details of a freshly set
+// up replica set or cluster
+func printTopology(engine databases.Engine, topology *databases.Topology) {
+	fmt.Printf("%s %s set up successfully!\n", engine.DisplayName(), topology.Name)
+	fmt.Printf("Members:\n")
+	for _, member := range topology.Members {
+		opts := member.Options()
+		fmt.Printf("  %s (%s): %s:%s\n", opts.Name, opts.Role, opts.ConnectHost(), opts.HostPort)
+	}
+	if topology.Primary != "" {
+		fmt.Printf("Primary: %s\n", topology.Primary)
+	}
+	fmt.Printf("Network: %s\n", engine.Options().Network)
+	fmt.Printf("URI: %s\n", topology.ConnectionInfo.URI())
+	if topology.HostsEntry != "" {
+		fmt.Printf("Clients on this machine need the member names to resolve, e.g. with this /etc/hosts line:\n  %s\n", topology.HostsEntry)
+	}
+	if _, ok := engine.(databases.ReplicationReporter); ok {
+		fmt.Printf("Run 'dockerdb replication status %s' to see the replication lag.\n", engine.Options().Name)
+	}
+}
 
-        err := databases.SetupMySQLContainer(config)
-        if err != nil {
-            fmt.Printf("Error setting up MySQL container: %v\n", err)
-            return
-        }
+// printConnectionInfo prints the connection details of a freshly set up engine
+func printConnectionInfo(engine databases.Engine) {
+	info := engine.ConnectionInfo()
+	fmt.Printf("%s container set up successfully!\n", engine.DisplayName())
+	fmt.Printf("Connection details:\n")
+	fmt.Printf("  Host: %s\n", info.Host)
+	fmt.Printf("  Port: %s\n", info.Port)
+	if info.Database != "" {
+		fmt.Printf("  Database: %s\n", info.Database)
+	}
+	if info.User != "" {
+		fmt.Printf("  User: %s\n", info.User)
+	}
+	if network := engine.Options().Network; network != "" {
+		fmt.Printf("  Network: %s\n", network)
+	}
+	fmt.Printf("  URI: %s\n", info.Redacted())
+	fmt.Printf("Run 'dockerdb connect-info %s --format env' to export it with the password.\n", engine.Options().Name)
+}
 
-        fmt.Println("MySQL container set up successfully!")
-        fmt.Printf("Connection details:\n")
-        fmt.Printf("  Host: localhost\n")
-        fmt.Printf("  Port: %s\n", port)
-        fmt.Printf("  Database: %s\n", dbName)
-        fmt.Printf("  User: %s\n", user)
-        if network != "" {
-            fmt.Printf("  Network: %s\n", network)
-        }
-    },
+var mysqlCmd = &cobra.Command{
+	Use:   "mysql",
+	Short: "Set up a MySQL Docker container",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in, err := newInputs(cmd)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Setting up MySQL Docker container...")
+
+		config := &databases.MySQLConfig{
+			ContainerOptions: in.containerOptions("mysql", "Image Tag (latest, 8.0, 5.7, etc)"),
+			RootPassword:     in.secret("root-password", "DB Root Password", true),
+			DatabaseName:     in.get("database", "Database Name"),
+			User:             in.get("user", "DB User"),
+			Password:         in.secret("password", "DB User Password", true),
+		}
+		if err := in.err(); err != nil {
+			return err
+		}
+
+		if replicas, _ := cmd.Flags().GetInt("replicas"); replicas > 0 {
+			return runTopology(cmd, in, config, func(ctx context.Context, backend databases.Backend) (*databases.Topology, error) {
+				return databases.ProvisionReplicas(ctx, backend, config, replicas)
+			})
+		}
+		return runSetup(cmd.Context(), in, config)
+	},
 }
 
 var mariadbCmd = &cobra.Command{
-    Use:   "mariadb",
-    Short: "Set up a MariaDB Docker container",
-    Run: func(cmd *cobra.Command, args []string) {
-        fmt.Println("Setting up MariaDB Docker container...")
-
-        // Prompt for configuration
-        containerName := promptForInput("Container Name", "mariadb-db")
-        imageTag := promptForInput("Image Tag (latest, 10.11, 10.6, etc)", "latest")
-        port := promptForInput("DB Port", "3306")
-        rootPassword := promptForInput("DB Root Password", "")
-        dbName := promptForInput("Database Name", "mydb")
-        user := promptForInput("DB User", "user")
-        userPassword := promptForInput("DB User Password", "")
-        volume := promptForInput("Data Volume", "mariadb_data")
-        network := promptForInput("Docker Network (leave empty for no specific network)", "")
-
-        if rootPassword == "" {
-            fmt.Println("Error: Root password cannot be empty")
-            return
-        }
-
-        if userPassword == "" {
-            fmt.Println("Error: User password cannot be empty")
-            return
-        }
-
-        // Set up MariaDB container
-        config := databases.MariaDBConfig{
-            Name:         containerName,
-            Image:        "mariadb:" + imageTag,
-            Port:         port,
-            RootPassword: rootPassword,
-            DatabaseName: dbName,
-            User:         user,
-            Password:     userPassword,
-            Volume:       volume,
-            Network:      network,
-        }
-
-        err := databases.SetupMariaDBContainer(config)
-        if err != nil {
-            fmt.Printf("Error setting up MariaDB container: %v\n", err)
-            return
-        }
-
-        fmt.Println("MariaDB container set up successfully!")
-        fmt.Printf("Connection details:\n")
-        fmt.Printf("  Host: localhost\n")
-        fmt.Printf("  Port: %s\n", port)
-        fmt.Printf("  Database: %s\n", dbName)
-        fmt.Printf("  User: %s\n", user)
-        if network != "" {
-            fmt.Printf("  Network: %s\n", network)
-        }
-    },
+	Use:   "mariadb",
+	Short: "Set up a MariaDB Docker container",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in, err := newInputs(cmd)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Setting up MariaDB Docker container...")
+
+		config := &databases.MariaDBConfig{
+			ContainerOptions: in.containerOptions("mariadb", "Image Tag (latest, 10.11, 10.6, etc)"),
+			RootPassword:     in.secret("root-password", "DB Root Password", true),
+			DatabaseName:     in.get("database", "Database Name"),
+			User:             in.get("user", "DB User"),
+			Password:         in.secret("password", "DB User Password", true),
+		}
+		if err := in.err(); err != nil {
+			return err
+		}
+
+		if replicas, _ := cmd.Flags().GetInt("replicas"); replicas > 0 {
+			return runTopology(cmd, in, config, func(ctx context.Context, backend databases.Backend) (*databases.Topology, error) {
+				return databases.ProvisionReplicas(ctx, backend, config, replicas)
+			})
+		}
+		return runSetup(cmd.Context(), in, config)
+	},
 }
 
 var postgresCmd = &cobra.Command{
-    Use:   "postgres",
-    Short: "Set up a PostgreSQL Docker container",
-    Run: func(cmd *cobra.Command, args []string) {
-        fmt.Println("Setting up PostgreSQL Docker container...")
-
-        // Prompt for configuration
-        containerName := promptForInput("Container Name", "postgres-db")
-        imageTag := promptForInput("Image Tag (latest, 16, 15, 14, etc)", "latest")
-        port := promptForInput("DB Port", "5432")
-        dbName := promptForInput("Database Name", "postgres")
-        user := promptForInput("DB User", "postgres")
-        password := promptForInput("DB User Password", "")
-        volume := promptForInput("Data Volume", "postgres_data")
-        network := promptForInput("Docker Network (leave empty for no specific network)", "")
-
-        if password == "" {
-            fmt.Println("Error: Password cannot be empty")
-            return
-        }
-
-        // Set up PostgreSQL container
-        config := databases.PostgresConfig{
-            Name:     containerName,
-            Image:    "postgres:" + imageTag,
-            Port:     port,
-            User:     user,
-            Password: password,
-            Database: dbName,
-            Volume:   volume,
-            Network:  network,
-        }
-
-        err := databases.SetupPostgresContainer(config)
-        if err != nil {
-            fmt.Printf("Error setting up PostgreSQL container: %v\n", err)
-            return
-        }
-
-        fmt.Println("PostgreSQL container set up successfully!")
-        fmt.Printf("Connection details:\n")
-        fmt.Printf("  Host: localhost\n")
-        fmt.Printf("  Port: %s\n", port)
-        fmt.Printf("  Database: %s\n", dbName)
-        fmt.Printf("  User: %s\n", user)
-        if network != "" {
-            fmt.Printf("  Network: %s\n", network)
-        }
-    },
+	Use:   "postgres",
+	Short: "Set up a PostgreSQL Docker container",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in, err := newInputs(cmd)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Setting up PostgreSQL Docker container...")
+
+		config := &databases.PostgresConfig{
+			ContainerOptions: in.containerOptions("postgres", "Image Tag (latest, 16, 15, 14, etc)"),
+			Database:         in.get("database", "Database Name"),
+			User:             in.get("user", "DB User"),
+			Password:         in.secret("password", "DB User Password", true),
+		}
+		if err := in.err(); err != nil {
+			return err
+		}
+
+		if replicas, _ := cmd.Flags().GetInt("replicas"); replicas > 0 {
+			return runTopology(cmd, in, config, func(ctx context.Context, backend databases.Backend) (*databases.Topology, error) {
+				return databases.ProvisionReplicas(ctx, backend, config, replicas)
+			})
+		}
+		return runSetup(cmd.Context(), in, config)
+	},
 }
 
 var mongodbCmd = &cobra.Command{
-    Use:   "mongodb",
-    Short: "Set up a MongoDB Docker container",
-    Run: func(cmd *cobra.Command, args []string) {
-        fmt.Println("Setting up MongoDB Docker container...")
-
-        // Prompt for configuration
-        containerName := promptForInput("Container Name", "mongodb")
-        imageTag := promptForInput("Image Tag (latest, 7.0, 6.0, 5.0, etc)", "latest")
-        port := promptForInput("DB Port", "27017")
-        volume := promptForInput("Data Volume", "mongodb_data")
-        network := promptForInput("Docker Network (leave empty for no specific network)", "")
-
-        useAuth := promptForInput("Enable Authentication? (yes/no)", "no")
-        var user, password string
-        if strings.ToLower(useAuth) == "yes" {
-            user = promptForInput("Admin Username", "admin")
-            password = promptForInput("Admin Password", "")
-
-            if password == "" {
-                fmt.Println("Error: Admin password cannot be empty when authentication is enabled")
-                return
-            }
-        }
-
-        // Set up MongoDB container
-        config := &databases.MongoDBConfig{
-            Name:     containerName,
-            Image:    "mongo:" + imageTag,
-            Port:     port,
-            Volume:   volume,
-            User:     user,
-            Password: password,
-            Auth:     strings.ToLower(useAuth) == "yes",
-            Network:  network,
-        }
-
-        ctx := context.Background()
-        err := databases.SetupMongoDB(ctx, config)
-        if err != nil {
-            fmt.Printf("Error setting up MongoDB container: %v\n", err)
-            return
-        }
-
-        fmt.Println("MongoDB container set up successfully!")
-        fmt.Printf("Connection details:\n")
-        fmt.Printf("  Host: localhost\n")
-        fmt.Printf("  Port: %s\n", port)
-        if strings.ToLower(useAuth) == "yes" {
-            fmt.Printf("  User: %s\n", user)
-        }
-        if network != "" {
-            fmt.Printf("  Network: %s\n", network)
-        }
-    },
+	Use:   "mongodb",
+	Short: "Set up a MongoDB Docker container",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in, err := newInputs(cmd)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Setting up MongoDB Docker container...")
+
+		config := &databases.MongoDBConfig{
+			ContainerOptions: in.containerOptions("mongo", "Image Tag (latest, 7.0, 6.0, 5.0, etc)"),
+			Auth:             in.confirm("auth", "Enable Authentication?"),
+		}
+		if config.Auth {
+			config.User = in.get("user", "Admin Username")
+			config.Password = in.secret("password", "Admin Password", true)
+		}
+		if err := in.err(); err != nil {
+			return err
+		}
+
+		if size, _ := cmd.Flags().GetInt("replica-set"); size > 0 {
+			config.ReplicaSet, _ = cmd.Flags().GetString("replica-set-name")
+			return runTopology(cmd, in, config, func(ctx context.Context, backend databases.Backend) (*databases.Topology, error) {
+				return databases.ProvisionReplicaSet(ctx, backend, config, size)
+			})
+		}
+
+		return runSetup(cmd.Context(), in, config)
+	},
 }
 
 var redisCmd = &cobra.Command{
-    Use:   "redis",
-    Short: "Set up a Redis Docker container",
-    Run: func(cmd *cobra.Command, args []string) {
-        fmt.Println("Setting up Redis Docker container...")
-
-        // Prompt for configuration
-        containerName := promptForInput("Container Name", "redis")
-        imageTag := promptForInput("Image Tag (latest, 7.2, 7.0, alpine, etc)", "latest")
-        port := promptForInput("DB Port", "6379")
-        volume := promptForInput("Data Volume", "redis_data")
-        password := promptForInput("Password (optional)", "")
-        network := promptForInput("Docker Network (leave empty for no specific network)", "")
-
-        // Set up Redis container
-        config := &databases.RedisConfig{
-            Name:     containerName,
-            Image:    "redis:" + imageTag,
-            Port:     port,
-            Volume:   volume,
-            Password: password,
-            Network:  network,
-        }
-
-        err := databases.SetupRedisContainer(config)
-        if err != nil {
-            fmt.Printf("Error setting up Redis container: %v\n", err)
-            return
-        }
-
-        fmt.Println("Redis container set up successfully!")
-        fmt.Printf("Connection details:\n")
-        fmt.Printf("  Host: localhost\n")
-        fmt.Printf("  Port: %s\n", port)
-        if password != "" {
-            fmt.Printf("  Password: (configured)\n")
-        }
-        if network != "" {
-            fmt.Printf("  Network: %s\n", network)
-        }
-    },
-}
\ No newline at end of file
+	Use:   "redis",
+	Short: "Set up a Redis Docker container",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in, err := newInputs(cmd)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Setting up Redis Docker container...")
+
+		config := &databases.RedisConfig{
+			ContainerOptions: in.containerOptions("redis", "Image Tag (latest, 7.2, 7.0, alpine, etc)"),
+			Password:         in.secret("password", "Password (optional)", false),
+		}
+		config.MaxMemory, _ = cmd.Flags().GetString("maxmemory")
+		config.EvictionPolicy, _ = cmd.Flags().GetString("eviction-policy")
+		config.Persistence, _ = cmd.Flags().GetString("persistence")
+		config.AppendFsync, _ = cmd.Flags().GetString("appendfsync")
+		aclUsers, _ := cmd.Flags().GetStringSlice("acl-user")
+		for _, spec := range aclUsers {
+			user, err := in.aclUser(spec)
+			if err != nil {
+				return err
+			}
+			config.ACLUsers = append(config.ACLUsers, user)
+		}
+		if err := in.err(); err != nil {
+			return err
+		}
+
+		replicas, _ := cmd.Flags().GetInt("replicas")
+		sentinel, _ := cmd.Flags().GetBool("sentinel")
+		cluster, _ := cmd.Flags().GetBool("cluster")
+		switch {
+		case cluster && (sentinel || replicas > 0):
+			return fmt.Errorf("--cluster cannot be combined with --sentinel or --replicas")
+		case cluster:
+			return runTopology(cmd, in, config, func(ctx context.Context, backend databases.Backend) (*databases.Topology, error) {
+				return databases.ProvisionCluster(ctx, backend, config)
+			})
+		case sentinel:
+			if replicas == 0 {
+				replicas = 2
+			}
+			return runTopology(cmd, in, config, func(ctx context.Context, backend databases.Backend) (*databases.Topology, error) {
+				return databases.ProvisionSentinel(ctx, backend, config, replicas)
+			})
+		case replicas > 0:
+			return runTopology(cmd, in, config, func(ctx context.Context, backend databases.Backend) (*databases.Topology, error) {
+				return databases.ProvisionReplicas(ctx, backend, config, replicas)
+			})
+		}
+		return runSetup(cmd.Context(), in, config)
+	},
+}
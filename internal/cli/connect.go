@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"dockerdb/internal/databases"
+	"dockerdb/internal/docker"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	connectInfoCmd.Flags().StringP("format", "f", "text", "Output format: text, uri, env, export or json")
+	connectInfoCmd.Flags().String("var", "DATABASE_URL", "Variable name used by the env and export formats")
+	rootCmd.AddCommand(connectInfoCmd)
+}
+
+// writeConnectionInfo renders info in the given format
+func writeConnectionInfo(w io.Writer, info databases.ConnectionInfo, format, variable string) error {
+	switch format {
+	case "text":
+		fmt.Fprintf(w, "Host: %s\n", info.Host)
+		fmt.Fprintf(w, "Port: %s\n", info.Port)
+		if info.Database != "" {
+			fmt.Fprintf(w, "Database: %s\n", info.Database)
+		}
+		if info.User != "" {
+			fmt.Fprintf(w, "User: %s\n", info.User)
+		}
+		if info.Password != "" {
+			fmt.Fprintf(w, "Password: %s\n", info.Password)
+		}
+		fmt.Fprintf(w, "URI: %s\n", info.URI())
+	case "uri":
+		fmt.Fprintln(w, info.URI())
+	case "env":
+		fmt.Fprintf(w, "%s=%s\n", variable, info.URI())
+	case "export":
+		fmt.Fprintf(w, "export %s=%s\n", variable, shellQuote(info.URI()))
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			databases.ConnectionInfo
+			URI string `json:"uri"`
+		}{info, info.URI()})
+	default:
+		return fmt.Errorf("unknown format %q (expected text, uri, env, export or json)", format)
+	}
+	return nil
+}
+
+// shellQuote quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+var connectInfoCmd = &cobra.Command{
+	Use:   "connect-info <name>",
+	Short: "Show the connection details of a dockerdb container",
+	Long: `Show the connection details of a dockerdb container, read from the container itself.
+
+Use --format env or --format export to produce lines for a .env file or a shell:
+
+  dockerdb connect-info postgres-db --format env >> .env
+  eval "$(dockerdb connect-info postgres-db --format export)"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		variable, _ := cmd.Flags().GetString("var")
+
+		client, err := docker.NewDockerClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		info, err := databases.Inspect(cmd.Context(), client, args[0])
+		if err != nil {
+			return err
+		}
+		engine, err := databases.FromContainer(info)
+		if err != nil {
+			return err
+		}
+		return writeConnectionInfo(os.Stdout, engine.ConnectionInfo(), format, variable)
+	},
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"fmt"
+
+	"dockerdb/internal/databases"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addSetupFlags(clickhouseCmd, "clickhouse", "9000", "clickhouse_data")
+	addInitFlag(clickhouseCmd)
+	addPasswordFlags(clickhouseCmd, "DB user password")
+	clickhouseCmd.Flags().String("database", "default", "Database name")
+	clickhouseCmd.Flags().String("user", "default", "DB user")
+
+	addSetupFlags(cockroachdbCmd, "cockroachdb", "26257", "cockroach_data")
+	addInitFlag(cockroachdbCmd)
+	addPasswordFlags(cockroachdbCmd, "DB user password (optional; runs the node in secure mode)")
+	cockroachdbCmd.Flags().String("database", "defaultdb", "Database name")
+	cockroachdbCmd.Flags().String("user", "root", "DB user, "+cockroachPasswordUser+" when a password is set (root cannot have one)")
+
+	addSetupFlags(cassandraCmd, "cassandra", "9042", "cassandra_data")
+	cassandraCmd.Flags().String("cluster-name", "dockerdb", "Cluster name")
+
+	addSetupFlags(elasticsearchCmd, "elasticsearch", "9200", "elasticsearch_data")
+	setDefaultTag(elasticsearchCmd, databases.ElasticsearchTag)
+	addPasswordFlags(elasticsearchCmd, "Password of the elastic user (optional; enables security)")
+
+	addSetupFlags(opensearchCmd, "opensearch", "9200", "opensearch_data")
+	addPasswordFlags(opensearchCmd, "Password of the admin user (optional; enables security on OpenSearch 2.12+)")
+
+	rootCmd.AddCommand(clickhouseCmd)
+	rootCmd.AddCommand(cockroachdbCmd)
+	rootCmd.AddCommand(cassandraCmd)
+	rootCmd.AddCommand(elasticsearchCmd)
+	rootCmd.AddCommand(opensearchCmd)
+}
+
+// setDefaultTag replaces the default of --tag for images without a latest tag
+func setDefaultTag(cmd *cobra.Command, tag string) {
+	flag := cmd.Flags().Lookup("tag")
+	flag.DefValue = tag
+	_ = flag.Value.Set(tag)
+}
+
+var clickhouseCmd = &cobra.Command{
+	Use:   "clickhouse",
+	Short: "Set up a ClickHouse Docker container",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in, err := newInputs(cmd)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Setting up ClickHouse Docker container...")
+
+		config := &databases.ClickHouseConfig{
+			ContainerOptions: in.containerOptions("clickhouse/clickhouse-server", "Image Tag (latest, 25.8, 24.8, etc)"),
+			Database:         in.get("database", "Database Name"),
+			User:             in.get("user", "DB User"),
+			Password:         in.secret("password", "DB User Password", true),
+		}
+		if err := in.err(); err != nil {
+			return err
+		}
+
+		return runSetup(cmd.Context(), in, config)
+	},
+}
+
+var cockroachdbCmd = &cobra.Command{
+	Use:   "cockroachdb",
+	Short: "Set up a single-node CockroachDB Docker container",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in, err := newInputs(cmd)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Setting up CockroachDB Docker container...")
+
+		config := &databases.CockroachDBConfig{
+			ContainerOptions: in.containerOptions("cockroachdb/cockroach", "Image Tag (latest, v25.2, v24.3, etc)"),
+			Database:         in.get("database", "Database Name"),
+			Password:         in.secret("password", "DB User Password (optional)", false),
+		}
+		// root logs in with a client certificate, so the password belongs
+		// to another user unless one was chosen
+		if user := cmd.Flags().Lookup("user"); config.Password != "" && !user.Changed {
+			user.DefValue = cockroachPasswordUser
+			user.Value.Set(cockroachPasswordUser)
+		}
+		config.User = in.get("user", "DB User")
+		if err := in.err(); err != nil {
+			return err
+		}
+
+		return runSetup(cmd.Context(), in, config)
+	},
+}
+
+// cockroachPasswordUser is the default CockroachDB user when a password is
+// set
+const cockroachPasswordUser = "app"
+
+var cassandraCmd = &cobra.Command{
+	Use:   "cassandra",
+	Short: "Set up a single-node Cassandra Docker container",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in, err := newInputs(cmd)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Setting up Cassandra Docker container...")
+
+		config := &databases.CassandraConfig{
+			ContainerOptions: in.containerOptions("cassandra", "Image Tag (latest, 5.0, 4.1, etc)"),
+			ClusterName:      in.get("cluster-name", "Cluster Name"),
+		}
+		if err := in.err(); err != nil {
+			return err
+		}
+
+		return runSetup(cmd.Context(), in, config)
+	},
+}
+
+var elasticsearchCmd = &cobra.Command{
+	Use:   "elasticsearch",
+	Short: "Set up a single-node Elasticsearch Docker container",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in, err := newInputs(cmd)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Setting up Elasticsearch Docker container...")
+
+		config := &databases.ElasticsearchConfig{
+			ContainerOptions: in.containerOptions("elasticsearch", "Image Tag ("+databases.ElasticsearchTag+", 8.19.0, etc; there is no latest)"),
+			Password:         in.secret("password", "Password of the elastic user (optional)", false),
+		}
+		if err := in.err(); err != nil {
+			return err
+		}
+
+		return runSetup(cmd.Context(), in, config)
+	},
+}
+
+var opensearchCmd = &cobra.Command{
+	Use:   "opensearch",
+	Short: "Set up a single-node OpenSearch Docker container",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in, err := newInputs(cmd)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Setting up OpenSearch Docker container...")
+
+		config := &databases.OpenSearchConfig{
+			ContainerOptions: in.containerOptions("opensearchproject/opensearch", "Image Tag (latest, 3, 2, etc)"),
+			Password:         in.secret("password", "Password of the admin user (optional)", false),
+		}
+		if err := in.err(); err != nil {
+			return err
+		}
+
+		return runSetup(cmd.Context(), in, config)
+	},
+}
@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"dockerdb/internal/databases"
+
+	"github.com/spf13/cobra"
+)
+
+// stdin is shared by every prompt so buffered input is not lost between them
+var stdin = bufio.NewReader(os.Stdin)
+
+// promptForInput asks the user for input with the given prompt text
+func promptForInput(prompt string, defaultValue string) string {
+	if defaultValue != "" {
+		fmt.Printf("%s (%s): ", prompt, defaultValue)
+	} else {
+		fmt.Printf("%s: ", prompt)
+	}
+	input, _ := stdin.ReadString('\n')
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return defaultValue
+	}
+	return input
+}
+
+// inputs resolves configuration values from command-line flags, falling back
+// to interactive prompts unless the command runs non-interactively
+type inputs struct {
+	cmd            *cobra.Command
+	nonInteractive bool
+	missing        []string
+	missingSecret  bool
+	// generate creates every password not given on the command line
+	generate    bool
+	generated   []generatedSecret
+	passwordOut string
+	failure     error
+}
+
+// newInputs prepares value resolution for cmd. When --password-stdin is set
+// the password is read from stdin, which also disables prompting;
+// --password-file reads it from the first line of a file.
+func newInputs(cmd *cobra.Command) (*inputs, error) {
+	flags := cmd.Flags()
+	yes, _ := flags.GetBool("yes")
+	nonInteractive, _ := flags.GetBool("non-interactive")
+	in := &inputs{cmd: cmd, nonInteractive: yes || nonInteractive}
+	if flags.Lookup("generate-password") == nil {
+		return in, nil
+	}
+	in.generate, _ = flags.GetBool("generate-password")
+	in.passwordOut, _ = flags.GetString("password-out")
+
+	sources := 0
+	for _, name := range []string{"password", "password-stdin", "password-file"} {
+		if flags.Changed(name) {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return nil, fmt.Errorf("use only one of --password, --password-stdin and --password-file")
+	}
+	if in.passwordOut != "" && !in.generate {
+		return nil, fmt.Errorf("--password-out requires --generate-password")
+	}
+
+	if fromStdin, _ := flags.GetBool("password-stdin"); fromStdin {
+		password, err := stdin.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		if err := flags.Set("password", strings.TrimRight(password, "\r\n")); err != nil {
+			return nil, err
+		}
+		in.nonInteractive = true
+	}
+	if path, _ := flags.GetString("password-file"); path != "" {
+		password, err := readPasswordFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := flags.Set("password", password); err != nil {
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+// get returns the flag value when given on the command line. Otherwise it
+// prompts with the flag default, or returns the default when non-interactive.
+func (in *inputs) get(flag, prompt string) string {
+	f := in.cmd.Flags().Lookup(flag)
+	if f.Changed || in.nonInteractive {
+		return f.Value.String()
+	}
+	return promptForInput(prompt, f.DefValue)
+}
+
+// confirm resolves a boolean flag, prompting with a yes/no question
+func (in *inputs) confirm(flag, prompt string) bool {
+	f := in.cmd.Flags().Lookup(flag)
+	if f.Changed || in.nonInteractive {
+		value, _ := in.cmd.Flags().GetBool(flag)
+		return value
+	}
+	defaultValue := "no"
+	if f.DefValue == "true" {
+		defaultValue = "yes"
+	}
+	return strings.ToLower(promptForInput(prompt+" (yes/no)", defaultValue)) == "yes"
+}
+
+// err reports a failed prompt or every required value that was left empty
+func (in *inputs) err() error {
+	if in.failure != nil {
+		return in.failure
+	}
+	if len(in.missing) == 0 {
+		return nil
+	}
+	err := fmt.Errorf("missing required value(s): %s", strings.Join(in.missing, ", "))
+	if in.missingSecret {
+		err = fmt.Errorf("%w (or use --generate-password)", err)
+	}
+	return err
+}
+
+// addSetupFlags registers the flags shared by every database subcommand
+func addSetupFlags(cmd *cobra.Command, name, port, volume string) {
+	flags := cmd.Flags()
+	flags.String("name", name, "Container name")
+	flags.String("tag", "latest", "Image tag")
+	flags.String("port", port, `Host port, or "auto" for the first free port`)
+	flags.String("container-port", "", "Port the database listens on inside the container (default "+port+")")
+	flags.String("bind", databases.DefaultBindIP, "Host address to publish the port on (0.0.0.0 for all interfaces)")
+	flags.Bool("ipv6", false, "Also publish the port on the IPv6 counterpart of --bind (::1 or ::)")
+	flags.String("volume", volume, "Data volume")
+	flags.String("network", "", "Docker network (empty for no specific network)")
+	flags.Duration("timeout", 0, "How long to wait for the database to be ready (default depends on the engine)")
+	flags.BoolP("yes", "y", false, "Do not prompt; use flags and defaults and fail on missing required values")
+	flags.Bool("non-interactive", false, "Alias for --yes")
+}
+
+// addPasswordFlags registers the flags used to supply or generate
+// passwords. Commands with a --yes flag register it first, so that the
+// help of --password-stdin can mention it.
+func addPasswordFlags(cmd *cobra.Command, usage string) {
+	flags := cmd.Flags()
+	flags.String("password", "", usage+" (prefer --password-stdin or --password-file, which stay out of shell history)")
+	stdinUsage := "Read the password from stdin instead of prompting for it"
+	if flags.Lookup("yes") != nil {
+		stdinUsage = "Read the password from stdin (implies --yes)"
+	}
+	flags.Bool("password-stdin", false, stdinUsage)
+	flags.String("password-file", "", "Read the password from the first line of a file")
+	flags.Bool("generate-password", false, "Generate a strong random password for every password not given")
+	flags.String("password-out", "", "Write generated passwords to this file (mode 0600) instead of showing them")
+}
+
+// addInitFlag registers the flag listing init scripts for engines supporting them
+func addInitFlag(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("init", nil, "Init script files or directories run on first start (repeatable or comma separated)")
+}
+
+// containerOptions resolves the settings shared by every database subcommand
+func (in *inputs) containerOptions(repository, tagPrompt string) databases.ContainerOptions {
+	timeout, _ := in.cmd.Flags().GetDuration("timeout")
+	containerPort, _ := in.cmd.Flags().GetString("container-port")
+	bindIP, _ := in.cmd.Flags().GetString("bind")
+	ipv6, _ := in.cmd.Flags().GetBool("ipv6")
+	var initScripts []string
+	if in.cmd.Flags().Lookup("init") != nil {
+		initScripts, _ = in.cmd.Flags().GetStringSlice("init")
+	}
+	return databases.ContainerOptions{
+		Name:          in.get("name", "Container Name"),
+		Image:         repository + ":" + in.get("tag", tagPrompt),
+		HostPort:      in.get("port", "Host Port (or auto)"),
+		ContainerPort: containerPort,
+		BindIP:        bindIP,
+		IPv6:          ipv6,
+		Volume:        in.get("volume", "Data Volume"),
+		Network:       in.get("network", "Docker Network (leave empty for no specific network)"),
+		ReadyTimeout:  timeout,
+		InitScripts:   initScripts,
+	}
+}
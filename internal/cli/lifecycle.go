@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"dockerdb/internal/databases"
+	"dockerdb/internal/docker"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rmCmd.Flags().BoolP("volumes", "v", false, "Also remove the data volume and network if nothing else uses them")
+
+	rootCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(stopCmd)
+	rootCmd.AddCommand(restartCmd)
+	rootCmd.AddCommand(rmCmd)
+}
+
+// forEachContainer runs action for every named container with a shared Docker client
+func forEachContainer(cmd *cobra.Command, names []string, done string, action func(context.Context, databases.Backend, string) error) error {
+	client, err := docker.NewDockerClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	for _, name := range names {
+		if err := action(cmd.Context(), client, name); err != nil {
+			return err
+		}
+		fmt.Printf("%s: %s\n", name, done)
+	}
+	return nil
+}
+
+var startCmd = &cobra.Command{
+	Use:   "start <name>...",
+	Short: "Start stopped dockerdb containers",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return forEachContainer(cmd, args, "started", databases.Start)
+	},
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop <name>...",
+	Short: "Stop running dockerdb containers",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return forEachContainer(cmd, args, "stopped", databases.Stop)
+	},
+}
+
+var restartCmd = &cobra.Command{
+	Use:   "restart <name>...",
+	Short: "Restart dockerdb containers",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return forEachContainer(cmd, args, "restarted", databases.Restart)
+	},
+}
+
+var rmCmd = &cobra.Command{
+	Use:   "rm <name>...",
+	Short: "Remove dockerdb containers",
+	Long:  `Stop and remove dockerdb containers. Data volumes are kept unless --volumes is given.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		removeData, _ := cmd.Flags().GetBool("volumes")
+		return forEachContainer(cmd, args, "removed", func(ctx context.Context, backend databases.Backend, name string) error {
+			return databases.Remove(ctx, backend, name, removeData)
+		})
+	},
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"dockerdb/internal/databases"
+	"dockerdb/internal/docker"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	listCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	rootCmd.AddCommand(listCmd)
+}
+
+var listCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ps"},
+	Short:   "List the database containers created by dockerdb",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		if output != "table" && output != "json" {
+			return fmt.Errorf("unknown output format %q (expected table or json)", output)
+		}
+
+		client, err := docker.NewDockerClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		instances, err := databases.List(cmd.Context(), client)
+		if err != nil {
+			return err
+		}
+
+		if output == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(instances)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "NAME\tENGINE\tVERSION\tSTATUS\tPORT\tVOLUME\tNETWORK")
+		for _, i := range instances {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				i.Name, i.Engine, i.Version, i.Status, orDash(i.HostPort), orDash(i.Volume), orDash(i.Networks))
+		}
+		return w.Flush()
+	},
+}
+
+// orDash returns s, or "-" when s is empty, for table output
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
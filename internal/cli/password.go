@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+
+	"dockerdb/internal/databases"
+
+	"golang.org/x/term"
+)
+
+// passwordAlphabet avoids characters that need escaping in URIs and shells
+const passwordAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// passwordLength gives generated passwords about 142 bits of entropy
+const passwordLength = 24
+
+// generatePassword returns a random password from a cryptographic source
+func generatePassword() (string, error) {
+	max := big.NewInt(int64(len(passwordAlphabet)))
+	password := make([]byte, passwordLength)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate password: %w", err)
+		}
+		password[i] = passwordAlphabet[n.Int64()]
+	}
+	return string(password), nil
+}
+
+// readSecret prompts for a secret, hiding the input when stdin is a terminal
+func readSecret(prompt string) (string, error) {
+	fmt.Printf("%s: ", prompt)
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		secret, err := term.ReadPassword(fd)
+		fmt.Println()
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		return string(secret), nil
+	}
+	line, err := stdin.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readPasswordFile returns the first line of a password file
+func readPasswordFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password file: %w", err)
+	}
+	password, _, _ := strings.Cut(string(data), "\n")
+	password = strings.TrimRight(password, "\r")
+	if password == "" {
+		return "", fmt.Errorf("password file %s is empty", path)
+	}
+	return password, nil
+}
+
+// generatedSecret is a password created by --generate-password or by
+// leaving a required password prompt empty
+type generatedSecret struct {
+	flag  string
+	value string
+}
+
+// secret resolves a password flag without echoing it. Required secrets that
+// are left empty at the prompt, and any secret not given when
+// --generate-password is set, are generated.
+func (in *inputs) secret(flag, prompt string, required bool) string {
+	f := in.cmd.Flags().Lookup(flag)
+	if f.Changed {
+		return f.Value.String()
+	}
+	if in.generate {
+		return in.generateSecret(flag)
+	}
+	if in.nonInteractive {
+		value := f.Value.String()
+		if required && value == "" {
+			in.missing = append(in.missing, "--"+flag)
+			in.missingSecret = true
+		}
+		return value
+	}
+
+	if required {
+		prompt += " (leave empty to generate one)"
+	}
+	value, err := readSecret(prompt)
+	if err != nil {
+		in.failure = err
+		return ""
+	}
+	if value == "" && required {
+		return in.generateSecret(flag)
+	}
+	return value
+}
+
+// generateSecret creates a password for flag and remembers it so it can be
+// shown once after setup
+func (in *inputs) generateSecret(flag string) string {
+	password, err := generatePassword()
+	if err != nil {
+		in.failure = err
+		return ""
+	}
+	in.generated = append(in.generated, generatedSecret{flag: flag, value: password})
+	return password
+}
+
+// aclUser parses a name[:role] --acl-user value and generates the user's
+// password, which is reported with the other generated passwords
+func (in *inputs) aclUser(spec string) (databases.User, error) {
+	name, roleName, found := strings.Cut(spec, ":")
+	role := databases.RoleReadWrite
+	if found {
+		var err error
+		if role, err = databases.ParseRole(roleName); err != nil {
+			return databases.User{}, err
+		}
+	}
+	return databases.User{
+		Name:     name,
+		Password: in.generateSecret("acl-user-" + name),
+		Role:     role,
+	}, nil
+}
+
+// reportGenerated shows the generated passwords once, or writes them to the
+// --password-out file so they never appear on screen
+func (in *inputs) reportGenerated() error {
+	if len(in.generated) == 0 {
+		return nil
+	}
+	if in.passwordOut != "" {
+		var content strings.Builder
+		for _, s := range in.generated {
+			fmt.Fprintf(&content, "%s=%s\n", s.flag, s.value)
+		}
+		if err := os.WriteFile(in.passwordOut, []byte(content.String()), 0o600); err != nil {
+			return fmt.Errorf("failed to write generated passwords: %w", err)
+		}
+		fmt.Printf("Generated passwords written to %s\n", in.passwordOut)
+		return nil
+	}
+	fmt.Println("Generated passwords (shown only once, store them now):")
+	for _, s := range in.generated {
+		fmt.Printf("  %s: %s\n", s.flag, s.value)
+	}
+	return nil
+}
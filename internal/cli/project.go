@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"fmt"
+
+	"dockerdb/internal/config"
+	"dockerdb/internal/databases"
+	"dockerdb/internal/docker"
+
+	"github.com/docker/docker/api/types"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	upCmd.Flags().StringP("file", "f", config.DefaultFile, "Project file")
+	downCmd.Flags().StringP("file", "f", config.DefaultFile, "Project file")
+	downCmd.Flags().BoolP("volumes", "v", false, "Also remove data volumes and networks if nothing else uses them")
+
+	rootCmd.AddCommand(upCmd)
+	rootCmd.AddCommand(downCmd)
+}
+
+// loadProject reads the project file named by the --file flag
+func loadProject(cmd *cobra.Command) (*config.Project, error) {
+	file, _ := cmd.Flags().GetString("file")
+	return config.LoadProject(file)
+}
+
+var upCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Provision every database service listed in a project file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := loadProject(cmd)
+		if err != nil {
+			return err
+		}
+
+		// Build and validate every engine first so a typo fails before
+		// anything is created
+		engines := make([]databases.Engine, 0, len(project.Services))
+		for _, name := range project.Names() {
+			engine, err := project.Engine(name)
+			if err != nil {
+				return err
+			}
+			engines = append(engines, engine)
+		}
+
+		client, err := docker.NewDockerClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		// Check the containers that already exist before changing anything,
+		// so that up never starts one it did not create for the service
+		ctx := cmd.Context()
+		existing := make(map[string]types.ContainerJSON)
+		for _, engine := range engines {
+			name := engine.Options().Name
+			inspect, err := client.InspectContainer(ctx, name)
+			if docker.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to inspect %s: %w", name, err)
+			}
+			if inspect.Config == nil || inspect.Config.Labels[databases.LabelManaged] != "true" {
+				return fmt.Errorf("%s: %w, remove or rename it first", name, databases.ErrNotManaged)
+			}
+			if kind := inspect.Config.Labels[databases.LabelEngine]; kind != engine.Kind() {
+				return fmt.Errorf("%s: the existing container runs %s, not %s", name, kind, engine.Kind())
+			}
+			existing[name] = inspect
+		}
+
+		for _, engine := range engines {
+			name := engine.Options().Name
+			if inspect, ok := existing[name]; ok {
+				if !inspect.State.Running {
+					if err := client.StartContainer(ctx, name); err != nil {
+						return fmt.Errorf("failed to start %s: %w", name, err)
+					}
+				}
+				fmt.Printf("%s: container already exists, running\n", name)
+				continue
+			}
+
+			fmt.Printf("%s: setting up %s Docker container...\n", name, engine.DisplayName())
+			if err := databases.Provision(ctx, client, engine); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			printConnectionInfo(engine)
+		}
+		return nil
+	},
+}
+
+var downCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Stop and remove the database services listed in a project file",
+	Long:  `Stop and remove the containers of every service in the project file. Data volumes are kept unless --volumes is given.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := loadProject(cmd)
+		if err != nil {
+			return err
+		}
+
+		removeData, _ := cmd.Flags().GetBool("volumes")
+
+		client, err := docker.NewDockerClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		ctx := cmd.Context()
+		for _, name := range project.Names() {
+			if _, err := client.InspectContainer(ctx, name); docker.IsNotFound(err) {
+				fmt.Printf("%s: not found, skipping\n", name)
+				continue
+			}
+			if err := databases.Remove(ctx, client, name, removeData); err != nil {
+				return err
+			}
+			fmt.Printf("%s: removed\n", name)
+		}
+		return nil
+	},
+}
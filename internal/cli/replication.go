@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"dockerdb/internal/databases"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	replicationCmd.AddCommand(replicationStatusCmd)
+	rootCmd.AddCommand(replicationCmd)
+}
+
+var replicationCmd = &cobra.Command{
+	Use:   "replication",
+	Short: "Inspect primaries and replicas set up with --replicas",
+}
+
+var replicationStatusCmd = &cobra.Command{
+	Use:   "status <name>",
+	Short: "Show the replication state and lag",
+	Long: `Show the replication state of a container. On a primary every replica is
+listed with how far it is behind; on a replica its own state is shown.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withClient(cmd, func(ctx context.Context, backend databases.Backend) error {
+			status, err := databases.ReplicationStatus(ctx, backend, args[0])
+			if err != nil {
+				return fmt.Errorf("reading replication status of %s: %w", args[0], err)
+			}
+			printLines(status, "No replicas connected.")
+			return nil
+		})
+	},
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"fmt"
+
+	"dockerdb/internal/databases"
+	"dockerdb/internal/docker"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	flags := restoreCmd.Flags()
+	flags.Bool("create", false, "Create the container first if it does not exist")
+	flags.String("engine", "", "Engine of the container to create (default taken from the dump metadata)")
+	flags.String("tag", "", "Image tag of the container to create (default taken from the dump metadata)")
+	flags.String("port", "", `Host port of the container to create, or "auto" (default depends on the engine)`)
+	flags.String("user", "", "DB user of the container to create")
+	flags.String("database", "", "Database of the container to create")
+	flags.BoolP("yes", "y", false, "Do not prompt; fail when the container to create needs a password that was not given")
+	addPasswordFlags(restoreCmd, "Password of the container to create (also used as root password)")
+	rootCmd.AddCommand(restoreCmd)
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <name> <file>",
+	Short: "Load a dump into a dockerdb container",
+	Long: `Load a dump into a dockerdb container. The format is taken from the metadata
+written by 'dockerdb backup', or detected from the file contents: SQL dumps
+are fed to psql or mysql, PostgreSQL custom-format dumps to pg_restore,
+mongodump archives to mongorestore, and Redis RDB files replace the data file
+before Redis is restarted. Gzip-compressed dumps are decompressed on the fly.
+
+With --create the container is set up first when it does not exist yet:
+
+  dockerdb restore app-db fixtures.sql.gz --create --password-file app-db.secret`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, file := args[0], args[1]
+
+		client, err := docker.NewDockerClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		ctx := cmd.Context()
+		create, _ := cmd.Flags().GetBool("create")
+		if _, err := client.InspectContainer(ctx, name); create && docker.IsNotFound(err) {
+			in, err := newInputs(cmd)
+			if err != nil {
+				return err
+			}
+			engine, err := restoreEngine(cmd, in, name, file)
+			if err != nil {
+				return err
+			}
+			if err := databases.CheckRestore(engine, file); err != nil {
+				return fmt.Errorf("restoring %s: %w", name, err)
+			}
+			fmt.Printf("Setting up %s Docker container...\n", engine.DisplayName())
+			if err := databases.Provision(ctx, client, engine); err != nil {
+				return fmt.Errorf("setting up %s container: %w", engine.DisplayName(), err)
+			}
+			printConnectionInfo(engine)
+			if err := in.reportGenerated(); err != nil {
+				return err
+			}
+		}
+
+		if err := databases.Restore(ctx, client, name, file); err != nil {
+			return fmt.Errorf("restoring %s: %w", name, err)
+		}
+		fmt.Printf("Restored %s into %s\n", file, name)
+		return nil
+	},
+}
+
+// restoreEngine builds the engine of a container created by restore --create
+func restoreEngine(cmd *cobra.Command, in *inputs, name, file string) (databases.Engine, error) {
+	flags := cmd.Flags()
+	kind, _ := flags.GetString("engine")
+	tag, _ := flags.GetString("tag")
+
+	meta, err := databases.ReadBackupMetadata(file)
+	if err != nil {
+		return nil, err
+	}
+	if meta != nil {
+		if kind == "" {
+			kind = meta.Engine
+		}
+		if tag == "" {
+			tag = meta.Version
+		}
+	}
+	if kind == "" {
+		return nil, fmt.Errorf("%s has no metadata, use --engine to choose the engine to create", file)
+	}
+
+	engine, err := databases.NewEngine(kind)
+	if err != nil {
+		return nil, err
+	}
+	opts := engine.Options()
+	opts.Name = name
+	opts.Volume = name + "_data"
+	if tag != "" {
+		opts.SetTag(tag)
+	}
+	if port, _ := flags.GetString("port"); port != "" {
+		opts.HostPort = port
+	}
+
+	user, _ := flags.GetString("user")
+	database, _ := flags.GetString("database")
+	password := in.secret("password", engine.DisplayName()+" Password", databases.RequiresPassword(engine))
+	if err := in.err(); err != nil {
+		return nil, err
+	}
+	databases.Apply(engine, databases.Settings{
+		User:         user,
+		Password:     password,
+		RootPassword: password,
+		Database:     database,
+		Auth:         password != "",
+	})
+	return engine, nil
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+
+	"dockerdb/internal/databases"
+	"dockerdb/internal/docker"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rotatePasswordCmd.Flags().String("user", "", "User whose password changes (default: the container's main user)")
+	addPasswordFlags(rotatePasswordCmd, "New password")
+
+	rootCmd.AddCommand(rotatePasswordCmd)
+}
+
+var rotatePasswordCmd = &cobra.Command{
+	Use:   "rotate-password <name>",
+	Short: "Change a database user's password inside a running container",
+	Long: `Change a user's password inside a running dockerdb container and update the
+stored credentials. MySQL, MariaDB and PostgreSQL use ALTER USER, MongoDB uses
+db.changeUserPassword and Redis uses CONFIG SET requirepass for the default
+user or ACL SETUSER for others.
+
+Without --password, --password-stdin or --password-file you are prompted for
+the new password; leave it empty or pass --generate-password to generate one.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in, err := newInputs(cmd)
+		if err != nil {
+			return err
+		}
+		user, _ := cmd.Flags().GetString("user")
+		password := in.secret("password", "New Password", true)
+		if err := in.err(); err != nil {
+			return err
+		}
+
+		client, err := docker.NewDockerClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		engine, err := databases.RotatePassword(cmd.Context(), client, args[0], user, password)
+		if err != nil {
+			return fmt.Errorf("rotating password on %s: %w", args[0], err)
+		}
+		if user == "" {
+			user = databases.MainUser(engine)
+		}
+		fmt.Printf("Changed the password of %s on %s\n", user, args[0])
+		return in.reportGenerated()
+	},
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"dockerdb/internal/databases"
+	"dockerdb/internal/docker"
+	"dockerdb/internal/secrets"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	secretsShowCmd.Flags().StringP("output", "o", "text", "Output format: text or json")
+
+	secretsCmd.AddCommand(secretsShowCmd)
+	secretsCmd.AddCommand(secretsRotateCmd)
+	secretsCmd.AddCommand(secretsForgetCmd)
+	rootCmd.AddCommand(secretsCmd)
+}
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage the stored credentials of dockerdb containers",
+	Long: `dockerdb remembers the credentials of every container it creates in an
+encrypted file under $XDG_CONFIG_HOME/dockerdb (usually ~/.config/dockerdb),
+so commands such as shell, backup and connect-info work without asking again.`,
+}
+
+var secretsShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show the stored credentials of a container",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		if output != "text" && output != "json" {
+			return fmt.Errorf("unknown output format %q (expected text or json)", output)
+		}
+
+		store, err := secrets.Open()
+		if err != nil {
+			return err
+		}
+		creds, ok, err := store.Get(args[0])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("no stored credentials for %s", args[0])
+		}
+
+		if output == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(creds)
+		}
+		fmt.Printf("Engine: %s\n", creds.Engine)
+		if creds.User != "" {
+			fmt.Printf("User: %s\n", creds.User)
+		}
+		if creds.Password != "" {
+			fmt.Printf("Password: %s\n", creds.Password)
+		}
+		if creds.RootPassword != "" {
+			fmt.Printf("Root password: %s\n", creds.RootPassword)
+		}
+		fmt.Printf("Updated: %s\n", creds.Updated.Format("2006-01-02 15:04:05 MST"))
+		return nil
+	},
+}
+
+var secretsRotateCmd = &cobra.Command{
+	Use:   "rotate <name>",
+	Short: "Replace the main password of a running container with a generated one",
+	Long: `Generate a new password for the container's main user, change it inside the
+running database and update the stored credentials. Use 'dockerdb secrets show'
+to read the new password.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		password, err := generatePassword()
+		if err != nil {
+			return err
+		}
+
+		client, err := docker.NewDockerClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		engine, err := databases.RotatePassword(cmd.Context(), client, args[0], "", password)
+		if err != nil {
+			return fmt.Errorf("rotating %s: %w", args[0], err)
+		}
+		fmt.Printf("Rotated the password of %s on %s\n", databases.MainUser(engine), args[0])
+		return nil
+	},
+}
+
+var secretsForgetCmd = &cobra.Command{
+	Use:   "forget <name>",
+	Short: "Remove the stored credentials of a container",
+	Long: `Remove the stored credentials of a container. Containers keep their passwords
+in files rather than in their configuration, so afterwards commands that log
+in to the database, such as shell and backup, no longer know them.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		forgotten, err := databases.ForgetCredentials(args[0])
+		if err != nil {
+			return err
+		}
+		if !forgotten {
+			return fmt.Errorf("no stored credentials for %s", args[0])
+		}
+		fmt.Printf("Forgot the credentials of %s\n", args[0])
+		return nil
+	},
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"dockerdb/internal/databases"
+	"dockerdb/internal/docker"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+}
+
+var shellCmd = &cobra.Command{
+	Use:   "shell <name>",
+	Short: "Open the database's native client inside a dockerdb container",
+	Long: `Open the database's native client (psql, mysql, mariadb, mongosh, redis-cli,
+clickhouse-client, cockroach sql or cqlsh) inside a dockerdb container, logged in with the container's credentials.
+
+When stdin is not a terminal the client reads commands from it instead:
+
+  echo 'SELECT 1;' | dockerdb shell postgres-db`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := docker.NewDockerClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		ctx := cmd.Context()
+		info, err := databases.Inspect(ctx, client, args[0])
+		if err != nil {
+			return err
+		}
+		engine, err := databases.FromContainer(info)
+		if err != nil {
+			return err
+		}
+		sheller, ok := engine.(databases.Sheller)
+		if !ok {
+			return fmt.Errorf("%s has no interactive client", engine.DisplayName())
+		}
+		shell := sheller.ShellCommand()
+
+		opts := docker.ExecOptions{
+			Cmd:    shell.Cmd,
+			Env:    shell.Env,
+			Stdin:  os.Stdin,
+			Stdout: os.Stdout,
+			Stderr: os.Stderr,
+		}
+		fd := int(os.Stdin.Fd())
+		if term.IsTerminal(fd) {
+			opts.Tty = true
+			if width, height, err := term.GetSize(fd); err == nil {
+				opts.Width, opts.Height = uint(width), uint(height)
+			}
+			state, err := term.MakeRaw(fd)
+			if err != nil {
+				return fmt.Errorf("failed to set terminal to raw mode: %w", err)
+			}
+			defer term.Restore(fd, state)
+		}
+
+		code, err := client.Exec(ctx, info.ID, opts)
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return fmt.Errorf("%s client exited with code %d", engine.DisplayName(), code)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"dockerdb/internal/databases"
+	"dockerdb/internal/docker"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	snapshotCmd.Flags().StringP("output", "o", "table", "Output format when listing: table or json")
+	cloneCmd.Flags().String("port", "", "Host port (default: first free port)")
+
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(cloneCmd)
+}
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot [<name> <tag>]",
+	Short: "Copy a container's data volume into a tagged snapshot, or list snapshots",
+	Long: `Copy the data volume of a dockerdb container into a snapshot volume tagged
+<tag>. A running container is stopped during the copy and started again
+afterwards. Without arguments, list the existing snapshots.
+
+Snapshots are referred to as <name>:<tag>, see 'dockerdb clone'.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 && len(args) != 2 {
+			return fmt.Errorf("accepts either no arguments or <name> <tag>, received %d", len(args))
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := docker.NewDockerClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		if len(args) == 0 {
+			output, _ := cmd.Flags().GetString("output")
+			return listSnapshots(cmd, client, output)
+		}
+
+		snapshot, err := databases.CreateSnapshot(cmd.Context(), client, args[0], args[1])
+		if err != nil {
+			return fmt.Errorf("snapshotting %s: %w", args[0], err)
+		}
+		fmt.Printf("Snapshot %s saved in volume %s\n", snapshot.Ref, snapshot.Volume)
+		return nil
+	},
+}
+
+// listSnapshots prints every snapshot as a table or JSON
+func listSnapshots(cmd *cobra.Command, client *docker.DockerClient, output string) error {
+	if output != "table" && output != "json" {
+		return fmt.Errorf("unknown output format %q (expected table or json)", output)
+	}
+	snapshots, err := databases.ListSnapshots(cmd.Context(), client)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(snapshots)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SNAPSHOT\tENGINE\tIMAGE\tVOLUME\tCREATED")
+	for _, s := range snapshots {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Ref, s.Engine, s.Image, s.Volume, s.Created)
+	}
+	return w.Flush()
+}
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <name>:<tag> <new-name>",
+	Short: "Create a new container from a snapshot",
+	Long: `Create a new container whose data volume is a copy of a snapshot taken with
+'dockerdb snapshot'. The new container uses the same image and credentials as
+the original and listens on the first free port unless --port is given.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		port, _ := cmd.Flags().GetString("port")
+
+		client, err := docker.NewDockerClient()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		engine, err := databases.Clone(cmd.Context(), client, args[0], args[1], port)
+		if err != nil {
+			return fmt.Errorf("cloning %s: %w", args[0], err)
+		}
+		printConnectionInfo(engine)
+		return nil
+	},
+}
@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"dockerdb/internal/databases"
+	"dockerdb/internal/docker"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	userAddCmd.Flags().String("role", string(databases.RoleReadWrite), "Role preset: read-only, read-write or admin")
+	userAddCmd.Flags().String("database", "", `Database the role applies to (default: the container's database; "*" for all on MySQL/MariaDB)`)
+	addPasswordFlags(userAddCmd, "Password of the new user")
+	userRmCmd.Flags().String("database", "", "Database the user was created in (MongoDB) or whose objects it owns (PostgreSQL)")
+
+	userCmd.AddCommand(userAddCmd)
+	userCmd.AddCommand(userRmCmd)
+	userCmd.AddCommand(userListCmd)
+	dbCmd.AddCommand(dbCreateCmd)
+	dbCmd.AddCommand(dbDropCmd)
+	dbCmd.AddCommand(dbListCmd)
+	rootCmd.AddCommand(userCmd)
+	rootCmd.AddCommand(dbCmd)
+}
+
+// withClient runs action with a Docker client that is closed afterwards
+func withClient(cmd *cobra.Command, action func(context.Context, databases.Backend) error) error {
+	client, err := docker.NewDockerClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return action(cmd.Context(), client)
+}
+
+// printLines prints each line, or a note when there are none
+func printLines(lines []string, empty string) {
+	if len(lines) == 0 {
+		fmt.Println(empty)
+		return
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
+
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage additional database users inside a dockerdb container",
+}
+
+var userAddCmd = &cobra.Command{
+	Use:   "add <name> <user>",
+	Short: "Create a user with a role preset",
+	Long: `Create a user inside a running dockerdb container with one of the role presets:
+
+  read-only   read data
+  read-write  read and change data and schema, but not privileges
+  admin       every privilege on the database, including granting them
+
+SQL engines grant the role on the container's database unless --database is
+given. MongoDB users are created in the --database they get the role on.
+Redis users are ACL users (Redis 6.2+) with access to every key and channel.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		roleName, _ := cmd.Flags().GetString("role")
+		role, err := databases.ParseRole(roleName)
+		if err != nil {
+			return err
+		}
+		database, _ := cmd.Flags().GetString("database")
+
+		in, err := newInputs(cmd)
+		if err != nil {
+			return err
+		}
+		password := in.secret("password", "Password for "+args[1], true)
+		if err := in.err(); err != nil {
+			return err
+		}
+
+		err = withClient(cmd, func(ctx context.Context, backend databases.Backend) error {
+			return databases.AddUser(ctx, backend, args[0], databases.User{
+				Name:     args[1],
+				Password: password,
+				Role:     role,
+				Database: database,
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("adding user %s to %s: %w", args[1], args[0], err)
+		}
+		fmt.Printf("Created %s user %s on %s\n", role, args[1], args[0])
+		return in.reportGenerated()
+	},
+}
+
+var userRmCmd = &cobra.Command{
+	Use:   "rm <name> <user>",
+	Short: "Drop a user",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		database, _ := cmd.Flags().GetString("database")
+		err := withClient(cmd, func(ctx context.Context, backend databases.Backend) error {
+			return databases.RemoveUser(ctx, backend, args[0], args[1], database)
+		})
+		if err != nil {
+			return fmt.Errorf("removing user %s from %s: %w", args[1], args[0], err)
+		}
+		fmt.Printf("Removed user %s from %s\n", args[1], args[0])
+		return nil
+	},
+}
+
+var userListCmd = &cobra.Command{
+	Use:   "list <name>",
+	Short: "List the users of a database",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withClient(cmd, func(ctx context.Context, backend databases.Backend) error {
+			users, err := databases.ListUsers(ctx, backend, args[0])
+			if err != nil {
+				return fmt.Errorf("listing users of %s: %w", args[0], err)
+			}
+			printLines(users, "No users found.")
+			return nil
+		})
+	},
+}
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage additional databases inside a dockerdb container",
+}
+
+var dbCreateCmd = &cobra.Command{
+	Use:   "create <name> <database>",
+	Short: "Create a database",
+	Long: `Create a database inside a running dockerdb container. Combine it with
+'dockerdb user add --database' to give each service its own database and user.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := withClient(cmd, func(ctx context.Context, backend databases.Backend) error {
+			return databases.CreateDatabase(ctx, backend, args[0], args[1])
+		})
+		if err != nil {
+			return fmt.Errorf("creating database %s on %s: %w", args[1], args[0], err)
+		}
+		fmt.Printf("Created database %s on %s\n", args[1], args[0])
+		return nil
+	},
+}
+
+var dbDropCmd = &cobra.Command{
+	Use:   "drop <name> <database>",
+	Short: "Drop a database and all of its data",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := withClient(cmd, func(ctx context.Context, backend databases.Backend) error {
+			return databases.DropDatabase(ctx, backend, args[0], args[1])
+		})
+		if err != nil {
+			return fmt.Errorf("dropping database %s on %s: %w", args[1], args[0], err)
+		}
+		fmt.Printf("Dropped database %s on %s\n", args[1], args[0])
+		return nil
+	},
+}
+
+var dbListCmd = &cobra.Command{
+	Use:   "list <name>",
+	Short: "List the databases of a container",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withClient(cmd, func(ctx context.Context, backend databases.Backend) error {
+			names, err := databases.ListDatabases(ctx, backend, args[0])
+			if err != nil {
+				return fmt.Errorf("listing databases of %s: %w", args[0], err)
+			}
+			printLines(names, "No databases found.")
+			return nil
+		})
+	},
+}
@@ -1,32 +1,149 @@
 package config
 
 import (
-	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"dockerdb/internal/databases"
+
+	"gopkg.in/yaml.v3"
 )
 
-type Config struct {
-	DatabaseType string `json:"database_type"`
-	Host         string `json:"host"`
-	Port         int    `json:"port"`
-	Username     string `json:"username"`
-	Password     string `json:"password"`
-	DatabaseName string `json:"database_name"`
+// DefaultFile is the project file used when none is given
+const DefaultFile = "dockerdb.yaml"
+
+// Project describes a set of database services provisioned together
+type Project struct {
+	Services map[string]Service `yaml:"services"`
+
+	// dir is the directory of the project file; relative paths resolve against it
+	dir string
 }
 
-func LoadConfig(filePath string) (*Config, error) {
+// Service describes a single database container in a project file. The map
+// key in Project.Services is used as the container name.
+type Service struct {
+	Engine        string        `yaml:"engine"`
+	Tag           string        `yaml:"tag"`
+	Port          string        `yaml:"port"`
+	ContainerPort string        `yaml:"container_port"`
+	Bind          string        `yaml:"bind"`
+	IPv6          bool          `yaml:"ipv6"`
+	Volume        string        `yaml:"volume"`
+	Network       string        `yaml:"network"`
+	User          string        `yaml:"user"`
+	Password      string        `yaml:"password"`
+	RootPassword  string        `yaml:"root_password"`
+	Database      string        `yaml:"database"`
+	Auth          bool          `yaml:"auth"`
+	Init          []string      `yaml:"init"`
+	Timeout       time.Duration `yaml:"timeout"`
+
+	// Redis settings
+	MaxMemory      string `yaml:"maxmemory"`
+	EvictionPolicy string `yaml:"eviction_policy"`
+	Persistence    string `yaml:"persistence"`
+	AppendFsync    string `yaml:"appendfsync"`
+}
+
+// LoadProject reads and validates a project file
+func LoadProject(filePath string) (*Project, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	config := &Config{}
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(config)
+	project := &Project{}
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(project); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+	}
+	if len(project.Services) == 0 {
+		return nil, fmt.Errorf("%s defines no services", filePath)
+	}
+
+	project.dir, err = filepath.Abs(filepath.Dir(filePath))
 	if err != nil {
 		return nil, err
 	}
+	return project, nil
+}
 
-	return config, nil
+// Names returns the service names in a stable order
+func (p *Project) Names() []string {
+	names := make([]string, 0, len(p.Services))
+	for name := range p.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Engine builds the engine for the named service, starting from the engine
+// defaults and overriding every value set in the project file, and checks
+// its settings
+func (p *Project) Engine(name string) (databases.Engine, error) {
+	svc, ok := p.Services[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown service %q", name)
+	}
+
+	engine, err := databases.NewEngine(svc.Engine)
+	if err != nil {
+		return nil, fmt.Errorf("service %s: %w", name, err)
+	}
+
+	opts := engine.Options()
+	opts.Name = name
+	if svc.Tag != "" {
+		opts.SetTag(svc.Tag)
+	}
+	if svc.Port != "" {
+		opts.HostPort = svc.Port
+	}
+	opts.ContainerPort = svc.ContainerPort
+	if svc.Bind != "" {
+		opts.BindIP = svc.Bind
+	}
+	opts.IPv6 = svc.IPv6
+	if svc.Volume != "" {
+		opts.Volume = svc.Volume
+	}
+	if svc.Network != "" {
+		opts.Network = svc.Network
+	}
+	opts.ReadyTimeout = svc.Timeout
+	for _, path := range svc.Init {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(p.dir, path)
+		}
+		opts.InitScripts = append(opts.InitScripts, path)
+	}
+
+	databases.Apply(engine, databases.Settings{
+		User:         svc.User,
+		Password:     svc.Password,
+		RootPassword: svc.RootPassword,
+		Database:     svc.Database,
+		Auth:         svc.Auth,
+	})
+
+	redisSettings := svc.MaxMemory != "" || svc.EvictionPolicy != "" || svc.Persistence != "" || svc.AppendFsync != ""
+	if redis, ok := engine.(*databases.RedisConfig); ok {
+		redis.MaxMemory = svc.MaxMemory
+		redis.EvictionPolicy = svc.EvictionPolicy
+		redis.Persistence = svc.Persistence
+		redis.AppendFsync = svc.AppendFsync
+	} else if redisSettings {
+		return nil, fmt.Errorf("service %s: maxmemory, eviction_policy, persistence and appendfsync only apply to redis", name)
+	}
+	if err := databases.Validate(engine); err != nil {
+		return nil, fmt.Errorf("service %s: %w", name, err)
+	}
+	return engine, nil
 }
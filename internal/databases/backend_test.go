@@ -0,0 +1,255 @@
+package databases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"dockerdb/internal/docker"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+)
+
+// fakeBackend keeps containers and volumes in memory. Started containers
+// keep running unless the test makes them exit, and every exec returns
+// execCode.
+type fakeBackend struct {
+	containers map[string]*types.ContainerJSON
+	volumes    map[string]map[string]string
+	networks   map[string]bool
+	listed     []types.Container
+
+	// exits makes started containers exit at once with exitCode
+	exits    bool
+	exitCode int
+	// execCode and execOutput are the result of every exec
+	execCode   int
+	execOutput string
+	logs       string
+
+	nextID int
+}
+
+var _ Backend = (*fakeBackend)(nil)
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		containers: make(map[string]*types.ContainerJSON),
+		volumes:    make(map[string]map[string]string),
+		networks:   make(map[string]bool),
+	}
+}
+
+// container finds a container by ID or name
+func (f *fakeBackend) container(id string) (*types.ContainerJSON, error) {
+	if c, ok := f.containers[id]; ok {
+		return c, nil
+	}
+	for _, c := range f.containers {
+		if c.Name == "/"+id {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("no such container: %s", id)
+}
+
+func (f *fakeBackend) PullImage(ctx context.Context, image string) error { return nil }
+
+func (f *fakeBackend) CreateNetwork(ctx context.Context, name string) error {
+	f.networks[name] = true
+	return nil
+}
+
+func (f *fakeBackend) CreateContainer(ctx context.Context, name string, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig) (string, error) {
+	if _, err := f.container(name); err == nil {
+		return "", fmt.Errorf("container name %s is already in use", name)
+	}
+	f.nextID++
+	id := fmt.Sprintf("c%d", f.nextID)
+	c := &types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:         id,
+			Name:       "/" + name,
+			State:      &types.ContainerState{},
+			HostConfig: hostConfig,
+		},
+		Config:          config,
+		NetworkSettings: &types.NetworkSettings{Networks: map[string]*network.EndpointSettings{}},
+	}
+	for _, bind := range hostConfig.Binds {
+		volume, destination, _ := strings.Cut(bind, ":")
+		if _, ok := f.volumes[volume]; !ok {
+			f.volumes[volume] = nil
+		}
+		c.Mounts = append(c.Mounts, types.MountPoint{Type: mount.TypeVolume, Name: volume, Destination: destination})
+	}
+	if networkingConfig != nil {
+		for name := range networkingConfig.EndpointsConfig {
+			if !f.networks[name] {
+				return "", fmt.Errorf("network %s not found", name)
+			}
+			c.NetworkSettings.Networks[name] = &network.EndpointSettings{IPAddress: fmt.Sprintf("172.18.0.%d", f.nextID)}
+		}
+	}
+	f.containers[id] = c
+	return id, nil
+}
+
+func (f *fakeBackend) StartContainer(ctx context.Context, id string) error {
+	c, err := f.container(id)
+	if err != nil {
+		return err
+	}
+	c.State.Running = !f.exits
+	c.State.ExitCode = f.exitCode
+	return nil
+}
+
+func (f *fakeBackend) InspectContainer(ctx context.Context, id string) (types.ContainerJSON, error) {
+	c, err := f.container(id)
+	if err != nil {
+		return types.ContainerJSON{}, err
+	}
+	return *c, nil
+}
+
+// ListContainers returns the containers using the volume or network named
+// by a filter, or else the containers set in listed
+func (f *fakeBackend) ListContainers(ctx context.Context, args filters.Args) ([]types.Container, error) {
+	volumes, networks := args.Get("volume"), args.Get("network")
+	if len(volumes) == 0 && len(networks) == 0 {
+		return f.listed, nil
+	}
+	var users []types.Container
+	for _, c := range f.containers {
+		used := false
+		for _, m := range c.Mounts {
+			used = used || contains(volumes, m.Name)
+		}
+		for name := range c.NetworkSettings.Networks {
+			used = used || contains(networks, name)
+		}
+		if used {
+			users = append(users, types.Container{ID: c.ID, Names: []string{c.Name}})
+		}
+	}
+	return users, nil
+}
+
+func (f *fakeBackend) StopContainer(ctx context.Context, id string) error {
+	c, err := f.container(id)
+	if err != nil {
+		return err
+	}
+	c.State.Running = false
+	return nil
+}
+
+func (f *fakeBackend) RestartContainer(ctx context.Context, id string) error {
+	return f.StartContainer(ctx, id)
+}
+
+func (f *fakeBackend) RemoveContainer(ctx context.Context, id string) error {
+	c, err := f.container(id)
+	if err != nil {
+		return err
+	}
+	delete(f.containers, c.ID)
+	return nil
+}
+
+func (f *fakeBackend) RemoveVolume(ctx context.Context, name string) error {
+	for _, c := range f.containers {
+		for _, m := range c.Mounts {
+			if m.Name == name {
+				return fmt.Errorf("volume %s is in use", name)
+			}
+		}
+	}
+	delete(f.volumes, name)
+	return nil
+}
+
+func (f *fakeBackend) NetworkExists(ctx context.Context, name string) (bool, error) {
+	return f.networks[name], nil
+}
+
+func (f *fakeBackend) RemoveNetwork(ctx context.Context, name string) error {
+	delete(f.networks, name)
+	return nil
+}
+
+func (f *fakeBackend) Exec(ctx context.Context, id string, opts docker.ExecOptions) (int, error) {
+	if opts.Stdout != nil {
+		io.WriteString(opts.Stdout, f.execOutput)
+	}
+	return f.execCode, nil
+}
+
+func (f *fakeBackend) CopyToContainer(ctx context.Context, id, dstPath string, content io.Reader) error {
+	_, err := io.Copy(io.Discard, content)
+	return err
+}
+
+func (f *fakeBackend) WaitContainer(ctx context.Context, id string) (int64, error) {
+	c, err := f.container(id)
+	if err != nil {
+		return 0, err
+	}
+	c.State.Running = false
+	return int64(c.State.ExitCode), nil
+}
+
+func (f *fakeBackend) CreateVolume(ctx context.Context, name string, labels map[string]string) error {
+	f.volumes[name] = labels
+	return nil
+}
+
+func (f *fakeBackend) InspectVolume(ctx context.Context, name string) (types.Volume, error) {
+	labels, ok := f.volumes[name]
+	if !ok {
+		return types.Volume{}, fmt.Errorf("no such volume: %s", name)
+	}
+	return types.Volume{Name: name, Labels: labels}, nil
+}
+
+// ListVolumes returns every volume carrying the labels of a label filter
+func (f *fakeBackend) ListVolumes(ctx context.Context, args filters.Args) ([]*types.Volume, error) {
+	var volumes []*types.Volume
+	for name, labels := range f.volumes {
+		matches := true
+		for _, label := range args.Get("label") {
+			key, value, hasValue := strings.Cut(label, "=")
+			got, ok := labels[key]
+			matches = matches && ok && (!hasValue || got == value)
+		}
+		if matches {
+			volumes = append(volumes, &types.Volume{Name: name, Labels: labels})
+		}
+	}
+	return volumes, nil
+}
+
+func (f *fakeBackend) VolumeExists(ctx context.Context, name string) (bool, error) {
+	_, ok := f.volumes[name]
+	return ok, nil
+}
+
+func (f *fakeBackend) Logs(ctx context.Context, id string, lines int) (string, error) {
+	return f.logs, nil
+}
+
+// contains reports whether value is one of values
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
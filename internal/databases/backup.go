@@ -0,0 +1,132 @@
+package databases
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"dockerdb/internal/docker"
+)
+
+// BackupMetadata describes a dump file. It is stored next to the dump with
+// a .json suffix.
+type BackupMetadata struct {
+	Container  string    `json:"container"`
+	Engine     string    `json:"engine"`
+	Image      string    `json:"image"`
+	Version    string    `json:"version"`
+	Format     string    `json:"format"`
+	Compressed bool      `json:"compressed"`
+	Created    time.Time `json:"created"`
+}
+
+// BackupFileName returns the default file name for a dump of container
+func BackupFileName(container, format string, compress bool, at time.Time) string {
+	name := fmt.Sprintf("%s-%s.%s", container, at.UTC().Format("20060102-150405"), format)
+	if compress {
+		name += ".gz"
+	}
+	return name
+}
+
+// Backup runs the engine's dump tool inside the named container and writes
+// the result to path, gzip-compressed if requested, along with its metadata.
+// An empty path selects BackupFileName in the current directory.
+func Backup(ctx context.Context, backend Backend, name, path string, compress bool) (string, error) {
+	info, err := Inspect(ctx, backend, name)
+	if err != nil {
+		return "", err
+	}
+	engine, err := FromContainer(info)
+	if err != nil {
+		return "", err
+	}
+	backuper, ok := engine.(Backuper)
+	if !ok {
+		return "", fmt.Errorf("%s does not support backups", engine.DisplayName())
+	}
+
+	meta := BackupMetadata{
+		Container:  name,
+		Engine:     engine.Kind(),
+		Image:      info.Config.Image,
+		Version:    info.Config.Labels[LabelVersion],
+		Format:     backuper.BackupFormat(),
+		Compressed: compress,
+		Created:    time.Now().UTC(),
+	}
+	if path == "" {
+		path = BackupFileName(name, meta.Format, compress, meta.Created)
+	}
+
+	if err := writeDump(ctx, backend, info.ID, backuper.BackupCommand(), path, compress); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(MetadataPath(path), append(data, '\n'), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write backup metadata: %w", err)
+	}
+	return path, nil
+}
+
+// MetadataPath returns the path of the metadata file belonging to a dump
+func MetadataPath(path string) string {
+	return path + ".json"
+}
+
+// writeDump streams the output of the dump command into a temporary file
+// next to path and renames it to path once the dump succeeded, so a failed
+// dump leaves an existing file at path untouched
+func writeDump(ctx context.Context, backend Backend, containerID string, command Command, path string, compress bool) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	var out io.Writer = file
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(file)
+		out = gz
+	}
+
+	var stderr bytes.Buffer
+	code, err := backend.Exec(ctx, containerID, docker.ExecOptions{
+		Cmd:    command.Cmd,
+		Env:    command.Env,
+		Stdout: out,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return fmt.Errorf("dump exited with code %d: %s", code, strings.TrimSpace(stderr.String()))
+	}
+
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("failed to compress backup: %w", err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package databases
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackup(t *testing.T) {
+	tests := []struct {
+		name     string
+		execCode int
+		wantErr  bool
+		want     string
+	}{
+		{name: "dump succeeds", want: "dump\n"},
+		{name: "dump fails", execCode: 1, wantErr: true, want: "previous backup\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempStore(t)
+			backend := newFakeBackend()
+			backend.execOutput = "dump\n"
+			backend.execCode = tt.execCode
+			engine := NewPostgresConfig()
+			engine.Name = "pg"
+			engine.Volume = "pg_data"
+			addContainer(t, backend, engine, true)
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, "pg.sql")
+			if err := os.WriteFile(path, []byte("previous backup\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := Backup(context.Background(), backend, "pg", path, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Backup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("backup file = %q, want %q", got, tt.want)
+			}
+			if _, err := os.Stat(MetadataPath(path)); (err == nil) == tt.wantErr {
+				t.Errorf("metadata exists = %v, want %v", err == nil, !tt.wantErr)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				if filepath.Ext(entry.Name()) == ".tmp" {
+					t.Errorf("temporary file %s was left behind", entry.Name())
+				}
+			}
+		})
+	}
+}
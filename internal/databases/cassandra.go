@@ -0,0 +1,88 @@
+package databases
+
+import (
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// CassandraConfig holds configuration for a single-node Cassandra container.
+// The official image has no settings for authentication, so clients connect
+// without credentials.
+type CassandraConfig struct {
+	ContainerOptions
+	ClusterName string
+}
+
+// cassandraPortProperty is the system property moving the native protocol port
+const cassandraPortProperty = "-Dcassandra.native_transport_port="
+
+// NewCassandraConfig returns a default Cassandra configuration
+func NewCassandraConfig() *CassandraConfig {
+	return &CassandraConfig{
+		ContainerOptions: ContainerOptions{
+			Name:     "cassandra",
+			Image:    "cassandra:latest",
+			HostPort: "9042",
+			BindIP:   DefaultBindIP,
+			Volume:   "cassandra_data",
+		},
+		ClusterName: "dockerdb",
+	}
+}
+
+func (c *CassandraConfig) Kind() string        { return "cassandra" }
+func (c *CassandraConfig) DisplayName() string { return "Cassandra" }
+func (c *CassandraConfig) DefaultPort() string { return "9042" }
+func (c *CassandraConfig) DataPath() string    { return "/var/lib/cassandra" }
+
+// Cmd moves the native protocol to a custom container port if one was chosen
+func (c *CassandraConfig) Cmd() []string {
+	if port := ContainerPort(c); port != c.DefaultPort() {
+		return []string{"cassandra", "-f", cassandraPortProperty + port}
+	}
+	return nil
+}
+
+// Env names the cluster, which cannot change once the node has data
+func (c *CassandraConfig) Env() []string {
+	if c.ClusterName == "" {
+		return nil
+	}
+	return []string{"CASSANDRA_CLUSTER_NAME=" + c.ClusterName}
+}
+
+// ReadyCheck runs a query through cqlsh. Cassandra takes a while to join
+// its own ring, so it gets more time than other engines.
+func (c *CassandraConfig) ReadyCheck() ReadyCheck {
+	return ReadyCheck{
+		Command: append(c.cqlshArgs(), "--execute", "SELECT release_version FROM system.local"),
+		Timeout: 180 * time.Second,
+	}
+}
+
+// ConnectionInfo returns the contact point of the node
+func (c *CassandraConfig) ConnectionInfo() ConnectionInfo {
+	return ConnectionInfo{
+		Scheme: "cassandra",
+		Host:   c.ConnectHost(),
+		Port:   c.HostPort,
+	}
+}
+
+// ShellCommand opens cqlsh
+func (c *CassandraConfig) ShellCommand() Command {
+	return Command{Cmd: c.cqlshArgs()}
+}
+
+// cqlshArgs returns the cqlsh invocation connecting to the node
+func (c *CassandraConfig) cqlshArgs() []string {
+	return []string{"cqlsh", "127.0.0.1", ContainerPort(c)}
+}
+
+// Load restores the cluster name from the Cassandra image environment
+// variables
+func (c *CassandraConfig) Load(config *container.Config) {
+	c.ClusterName = ""
+	loadEnv(envMap(config.Env), "CASSANDRA_CLUSTER_NAME", &c.ClusterName)
+}
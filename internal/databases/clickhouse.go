@@ -0,0 +1,145 @@
+package databases
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// ClickHouseConfig holds configuration for a ClickHouse container
+type ClickHouseConfig struct {
+	ContainerOptions
+	User     string
+	Password string
+	Database string
+}
+
+// clickhousePasswordFile holds the password read through CLICKHOUSE_PASSWORD_FILE
+const clickhousePasswordFile = "clickhouse_password"
+
+// NewClickHouseConfig returns a default ClickHouse configuration
+func NewClickHouseConfig() *ClickHouseConfig {
+	return &ClickHouseConfig{
+		ContainerOptions: ContainerOptions{
+			Name:     "clickhouse",
+			Image:    "clickhouse/clickhouse-server:latest",
+			HostPort: "9000",
+			BindIP:   DefaultBindIP,
+			Volume:   "clickhouse_data",
+		},
+		User:     "default",
+		Database: "default",
+	}
+}
+
+func (c *ClickHouseConfig) Kind() string             { return "clickhouse" }
+func (c *ClickHouseConfig) DisplayName() string      { return "ClickHouse" }
+func (c *ClickHouseConfig) DefaultPort() string      { return "9000" }
+func (c *ClickHouseConfig) DataPath() string         { return "/var/lib/clickhouse" }
+func (c *ClickHouseConfig) InitPath() string         { return "/docker-entrypoint-initdb.d" }
+func (c *ClickHouseConfig) InitExtensions() []string { return []string{".sh", ".sql", ".sql.gz"} }
+
+// Cmd moves the native protocol to a custom container port if one was
+// chosen. The image passes arguments after -- to the server as settings.
+func (c *ClickHouseConfig) Cmd() []string {
+	if port := ContainerPort(c); port != c.DefaultPort() {
+		return []string{"--", "--tcp_port=" + port}
+	}
+	return nil
+}
+
+// Env returns the environment variables understood by the ClickHouse
+// image. The user may manage other users and grants with SQL.
+func (c *ClickHouseConfig) Env() []string {
+	env := []string{
+		"CLICKHOUSE_USER=" + c.User,
+		"CLICKHOUSE_PASSWORD_FILE=" + secretFile(clickhousePasswordFile),
+		"CLICKHOUSE_DEFAULT_ACCESS_MANAGEMENT=1",
+	}
+	if c.Database != "" && c.Database != "default" {
+		env = append(env, "CLICKHOUSE_DB="+c.Database)
+	}
+	return env
+}
+
+// SecretFiles returns the password referenced by CLICKHOUSE_PASSWORD_FILE
+func (c *ClickHouseConfig) SecretFiles() map[string]string {
+	return map[string]string{clickhousePasswordFile: c.Password}
+}
+
+// Validate requires a password, without which the image only lets the user
+// connect from inside the container
+func (c *ClickHouseConfig) Validate() error {
+	if c.Password == "" {
+		return fmt.Errorf("ClickHouse needs a password for connections from outside the container")
+	}
+	return nil
+}
+
+// ReadyCheck runs a query in the configured database as the configured
+// user, which only succeeds once the image has created both
+func (c *ClickHouseConfig) ReadyCheck() ReadyCheck {
+	command := c.clientCommand("--query", "SELECT 1")
+	return ReadyCheck{
+		Command: command.Cmd,
+		Env:     command.Env,
+		Timeout: 60 * time.Second,
+	}
+}
+
+// ConnectionInfo returns the details needed to connect over the native protocol
+func (c *ClickHouseConfig) ConnectionInfo() ConnectionInfo {
+	return ConnectionInfo{
+		Scheme:   "clickhouse",
+		Host:     c.ConnectHost(),
+		Port:     c.HostPort,
+		Database: c.Database,
+		User:     c.User,
+		Password: c.Password,
+	}
+}
+
+// ShellCommand opens clickhouse-client connected to the configured database
+func (c *ClickHouseConfig) ShellCommand() Command {
+	return c.clientCommand()
+}
+
+// clientCommand runs clickhouse-client logged in as the configured user.
+// The credentials are read from a temporary client configuration file.
+func (c *ClickHouseConfig) clientCommand(args ...string) Command {
+	var config strings.Builder
+	config.WriteString("<config><user>")
+	xml.EscapeText(&config, []byte(c.User))
+	config.WriteString("</user><password>")
+	xml.EscapeText(&config, []byte(c.Password))
+	config.WriteString("</password></config>")
+	cmd := []string{"clickhouse-client", "--config-file", secretFileArg, "--port", ContainerPort(c), "--database", c.Database}
+	return secretFileCommand(config.String(), append(cmd, args...)...)
+}
+
+// Load restores the user and database from the ClickHouse image environment
+// variables
+func (c *ClickHouseConfig) Load(config *container.Config) {
+	env := envMap(config.Env)
+	loadEnv(env, "CLICKHOUSE_USER", &c.User)
+	loadEnv(env, "CLICKHOUSE_DB", &c.Database)
+}
+
+// Settings returns the settings that Configure changes
+func (c *ClickHouseConfig) Settings() Settings {
+	return Settings{User: c.User, Password: c.Password, Database: c.Database}
+}
+
+// Configure copies the non-empty settings onto the configuration
+func (c *ClickHouseConfig) Configure(s Settings) {
+	override(&c.Database, s.Database)
+	override(&c.User, s.User)
+	override(&c.Password, s.Password)
+}
+
+// RequiresPassword reports whether the image refuses to start without a
+// password
+func (c *ClickHouseConfig) RequiresPassword() bool { return true }
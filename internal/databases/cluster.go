@@ -0,0 +1,108 @@
+package databases
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// Shape of the Redis Clusters dockerdb creates: three masters sharing the
+// hash slots, each with one replica
+const (
+	redisClusterNodes    = 6
+	redisClusterReplicas = 1
+)
+
+// redisClusterBusOffset is added to a node's port to get the port of the
+// cluster bus the nodes talk to each other on
+const redisClusterBusOffset = 10000
+
+// ProvisionCluster starts a Redis Cluster of three masters and three
+// replicas (Redis 7+). The first node uses config's options and the others
+// are named after it with an index suffix. Every node listens on its host
+// port inside the container too and announces its name, so the redirects
+// clients follow work from the host once the names resolve. When a node
+// fails, the ones started so far are removed.
+func ProvisionCluster(ctx context.Context, backend Backend, config *RedisConfig) (*Topology, error) {
+	if config.Replication.Source != "" {
+		return nil, fmt.Errorf("Redis Cluster nodes cannot replicate from another server")
+	}
+	setup, err := newTopologySetup(ctx, backend, config)
+	if err != nil {
+		return nil, err
+	}
+	topology, err := provisionCluster(ctx, backend, setup, config)
+	if err != nil {
+		return nil, setup.undo(ctx, err)
+	}
+	return topology, nil
+}
+
+// provisionCluster sets up the nodes of ProvisionCluster, recording them
+// in setup
+func provisionCluster(ctx context.Context, backend Backend, setup *topologySetup, config *RedisConfig) (*Topology, error) {
+	config.Cluster = true
+	config.Network = topologyNetwork(&config.ContainerOptions)
+	config.Topology = config.Name
+	config.Role = RoleMember
+
+	base := config.ContainerOptions
+	topology := &Topology{Name: fmt.Sprintf("Cluster of %d nodes", redisClusterNodes)}
+	info := config.ConnectionInfo()
+	var addresses []string
+	for i := 0; i < redisClusterNodes; i++ {
+		node := config
+		if i > 0 {
+			copied := *config
+			copied.ContainerOptions = memberOptions(base, i, RoleMember)
+			node = &copied
+		}
+		if err := pinPort(ctx, backend, node); err != nil {
+			return nil, err
+		}
+		if port, _ := strconv.Atoi(node.HostPort); port+redisClusterBusOffset > 65535 {
+			return nil, fmt.Errorf("port %d leaves no room for the cluster bus port %d higher, choose a lower port", port, redisClusterBusOffset)
+		}
+		fmt.Printf("Starting cluster node %s...\n", node.Name)
+		if err := setup.provision(ctx, node); err != nil {
+			return nil, fmt.Errorf("failed to start node %s: %w", node.Name, err)
+		}
+		// redis-cli --cluster only accepts IP addresses
+		ip, err := networkIP(ctx, backend, node)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, net.JoinHostPort(ip, node.HostPort))
+		topology.Members = append(topology.Members, node)
+		info.Hosts = append(info.Hosts, memberAddress(node))
+	}
+
+	create := append([]string{"--cluster", "create"}, addresses...)
+	create = append(create, "--cluster-replicas", strconv.Itoa(redisClusterReplicas), "--cluster-yes")
+	if _, err := runCommand(ctx, backend, config.Name, config.cliCommand(create...)); err != nil {
+		return nil, fmt.Errorf("failed to create the cluster: %w", err)
+	}
+	if _, err := waitFor(ctx, backend, config.Name, "the cluster to cover every hash slot", topologyWait(config),
+		config.expectCommand("cluster_state:ok", "CLUSTER", "INFO")); err != nil {
+		return nil, err
+	}
+	topology.ConnectionInfo = info
+	topology.HostsEntry = hostsEntry(topology.Members)
+	return topology, nil
+}
+
+// networkIP returns the address of a container on its topology network
+func networkIP(ctx context.Context, backend Backend, engine Engine) (string, error) {
+	opts := engine.Options()
+	info, err := backend.InspectContainer(ctx, opts.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect %s: %w", opts.Name, err)
+	}
+	if info.NetworkSettings != nil {
+		if endpoint, ok := info.NetworkSettings.Networks[opts.Network]; ok && endpoint != nil && endpoint.IPAddress != "" {
+			return endpoint.IPAddress, nil
+		}
+	}
+	return "", fmt.Errorf("%s has no address on network %s", opts.Name, opts.Network)
+}
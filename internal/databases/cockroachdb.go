@@ -0,0 +1,166 @@
+package databases
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// CockroachDBConfig holds configuration for a single-node CockroachDB
+// container. Without a password the node runs in insecure mode; with one
+// the image generates certificates and clients log in with the password
+// over TLS.
+type CockroachDBConfig struct {
+	ContainerOptions
+	User     string
+	Password string
+	Database string
+}
+
+// cockroachPasswordFile is the secret file exported as COCKROACH_PASSWORD
+const cockroachPasswordFile = "cockroach-password"
+
+// NewCockroachDBConfig returns a default CockroachDB configuration
+func NewCockroachDBConfig() *CockroachDBConfig {
+	return &CockroachDBConfig{
+		ContainerOptions: ContainerOptions{
+			Name:     "cockroachdb",
+			Image:    "cockroachdb/cockroach:latest",
+			HostPort: "26257",
+			BindIP:   DefaultBindIP,
+			Volume:   "cockroach_data",
+		},
+		User:     "root",
+		Database: "defaultdb",
+	}
+}
+
+func (c *CockroachDBConfig) Kind() string             { return "cockroachdb" }
+func (c *CockroachDBConfig) DisplayName() string      { return "CockroachDB" }
+func (c *CockroachDBConfig) DefaultPort() string      { return "26257" }
+func (c *CockroachDBConfig) DataPath() string         { return "/cockroach/cockroach-data" }
+func (c *CockroachDBConfig) InitPath() string         { return "/docker-entrypoint-initdb.d" }
+func (c *CockroachDBConfig) InitExtensions() []string { return []string{".sh", ".sql"} }
+
+// Cmd starts a single node, insecure unless a password is set, on a custom
+// container port if one was chosen
+func (c *CockroachDBConfig) Cmd() []string {
+	cmd := []string{"start-single-node"}
+	if c.Password == "" {
+		cmd = append(cmd, "--insecure")
+	}
+	if port := ContainerPort(c); port != c.DefaultPort() {
+		cmd = append(cmd, "--listen-addr=:"+port)
+	}
+	return cmd
+}
+
+// Env returns the environment variables understood by the CockroachDB
+// image, except the password, which Entrypoint reads from a secret file
+func (c *CockroachDBConfig) Env() []string {
+	var env []string
+	if c.User != "root" {
+		env = append(env, "COCKROACH_USER="+c.User)
+	}
+	if c.Database != "defaultdb" {
+		env = append(env, "COCKROACH_DATABASE="+c.Database)
+	}
+	return env
+}
+
+// Entrypoint exports the password file as COCKROACH_PASSWORD before
+// running the image's entrypoint, since the image has no *_FILE variables
+func (c *CockroachDBConfig) Entrypoint() []string {
+	if c.Password == "" {
+		return nil
+	}
+	return secretEnvEntrypoint("COCKROACH_PASSWORD", cockroachPasswordFile, "/cockroach/cockroach.sh")
+}
+
+// SecretFiles returns the password read by Entrypoint
+func (c *CockroachDBConfig) SecretFiles() map[string]string {
+	if c.Password == "" {
+		return nil
+	}
+	return map[string]string{cockroachPasswordFile: c.Password}
+}
+
+// Validate rejects a password for root, which logs in with the client
+// certificate the image generates
+func (c *CockroachDBConfig) Validate() error {
+	if c.Password != "" && c.User == "root" {
+		return fmt.Errorf("CockroachDB sets passwords for users other than root only, choose one with --user")
+	}
+	return nil
+}
+
+// ReadyCheck runs a query in the configured database as the configured
+// user, which only succeeds once the image has created both
+func (c *CockroachDBConfig) ReadyCheck() ReadyCheck {
+	return ReadyCheck{
+		Command: []string{"cockroach", "sql", "--execute=SELECT 1"},
+		Env:     []string{"COCKROACH_URL=" + c.localURL()},
+		Timeout: 60 * time.Second,
+	}
+}
+
+// ConnectionInfo returns the details needed to connect with a PostgreSQL
+// driver
+func (c *CockroachDBConfig) ConnectionInfo() ConnectionInfo {
+	info := ConnectionInfo{
+		Scheme:   "postgresql",
+		Host:     c.ConnectHost(),
+		Port:     c.HostPort,
+		Database: c.Database,
+		User:     c.User,
+		Password: c.Password,
+		Query:    url.Values{"sslmode": {"disable"}},
+	}
+	if c.Password != "" {
+		info.Query.Set("sslmode", "require")
+	}
+	return info
+}
+
+// ShellCommand opens the built-in SQL client connected to the configured database
+func (c *CockroachDBConfig) ShellCommand() Command {
+	return Command{
+		Cmd: []string{"cockroach", "sql"},
+		Env: []string{"COCKROACH_URL=" + c.localURL()},
+	}
+}
+
+// localURL returns the connection URI used inside the container
+func (c *CockroachDBConfig) localURL() string {
+	info := c.ConnectionInfo()
+	info.Host = "127.0.0.1"
+	info.Port = ContainerPort(c)
+	return info.URI()
+}
+
+// Load restores the user and database from the CockroachDB image
+// environment variables. The password is only kept in a secret file and the
+// credential store.
+func (c *CockroachDBConfig) Load(config *container.Config) {
+	env := envMap(config.Env)
+	loadEnv(env, "COCKROACH_USER", &c.User)
+	loadEnv(env, "COCKROACH_DATABASE", &c.Database)
+}
+
+// Settings returns the settings that Configure changes
+func (c *CockroachDBConfig) Settings() Settings {
+	return Settings{User: c.User, Password: c.Password, Database: c.Database}
+}
+
+// Configure copies the non-empty settings onto the configuration
+func (c *CockroachDBConfig) Configure(s Settings) {
+	override(&c.Database, s.Database)
+	override(&c.User, s.User)
+	override(&c.Password, s.Password)
+}
+
+// RequiresPassword reports whether the image refuses to start without a
+// password
+func (c *CockroachDBConfig) RequiresPassword() bool { return false }
@@ -2,78 +2,182 @@ package databases
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"os/exec"
+	"path"
+
+	"dockerdb/internal/docker"
 
 	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
 )
 
-// PullImageIfNotExists pulls a Docker image if it doesn't exist locally
-// For use with Docker API client
-func PullImageIfNotExists(ctx context.Context, cli *client.Client, image string) error {
-	// Check if image exists locally
-	_, _, err := cli.ImageInspectWithRaw(ctx, image)
-	if err != nil {
-		if client.IsErrNotFound(err) {
-			// Image doesn't exist locally, pull it
-			fmt.Printf("Pulling image: %s...\n", image)
-			reader, err := cli.ImagePull(ctx, image, types.ImagePullOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to pull image: %w", err)
-			}
-			defer reader.Close()
-
-			// Wait for pull to complete
-			_, err = io.Copy(io.Discard, reader)
-			if err != nil {
-				return fmt.Errorf("error while pulling image: %w", err)
-			}
-			fmt.Printf("Successfully pulled image: %s\n", image)
-		} else {
-			return fmt.Errorf("failed to inspect image: %w", err)
-		}
+// Backend is the subset of Docker operations used to provision and manage engines.
+// It is satisfied by *docker.DockerClient.
+type Backend interface {
+	PullImage(ctx context.Context, image string) error
+	CreateNetwork(ctx context.Context, name string) error
+	NetworkExists(ctx context.Context, name string) (bool, error)
+	CreateContainer(ctx context.Context, name string, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig) (string, error)
+	StartContainer(ctx context.Context, containerID string) error
+	StopContainer(ctx context.Context, containerID string) error
+	RestartContainer(ctx context.Context, containerID string) error
+	RemoveContainer(ctx context.Context, containerID string) error
+	WaitContainer(ctx context.Context, containerID string) (int64, error)
+	InspectContainer(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ListContainers(ctx context.Context, args filters.Args) ([]types.Container, error)
+	CreateVolume(ctx context.Context, name string, labels map[string]string) error
+	InspectVolume(ctx context.Context, name string) (types.Volume, error)
+	ListVolumes(ctx context.Context, args filters.Args) ([]*types.Volume, error)
+	VolumeExists(ctx context.Context, name string) (bool, error)
+	RemoveVolume(ctx context.Context, name string) error
+	RemoveNetwork(ctx context.Context, name string) error
+	Exec(ctx context.Context, containerID string, opts docker.ExecOptions) (int, error)
+	CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader) error
+	Logs(ctx context.Context, containerID string, lines int) (string, error)
+}
+
+// Validate runs the engine's own checks of its settings, if it has any
+func Validate(engine Engine) error {
+	if validator, ok := engine.(Validator); ok {
+		return validator.Validate()
 	}
 	return nil
 }
 
-// PullImageWithCLI pulls a Docker image using the docker CLI
-func PullImageWithCLI(image string) error {
-	// Check if image exists
-	checkCmd := exec.Command("docker", "image", "inspect", image)
-	if err := checkCmd.Run(); err != nil {
-		// Image doesn't exist, pull it
-		fmt.Printf("Pulling image: %s...\n", image)
-		pullCmd := exec.Command("docker", "pull", image)
-		output, err := pullCmd.CombinedOutput()
-		if err != nil {
-			return fmt.Errorf("failed to pull image: %v, output: %s", err, output)
+// Provision pulls the engine's image, creates its network if needed, then
+// creates and starts the container and waits for it to become ready.
+func Provision(ctx context.Context, backend Backend, engine Engine) error {
+	opts := engine.Options()
+	name := engine.DisplayName()
+
+	if err := Validate(engine); err != nil {
+		return err
+	}
+
+	scripts, err := initScriptArchive(engine)
+	if err != nil {
+		return err
+	}
+	freshVolume, err := checkInitVolume(ctx, backend, engine)
+	if err != nil {
+		return err
+	}
+
+	if err := ResolvePort(ctx, backend, engine); err != nil {
+		return err
+	}
+
+	if err := backend.PullImage(ctx, opts.Image); err != nil {
+		return fmt.Errorf("failed to ensure %s image: %w", name, err)
+	}
+
+	// Create network if specified
+	if opts.Network != "" {
+		if err := backend.CreateNetwork(ctx, opts.Network); err != nil {
+			return err
+		}
+	}
+
+	port := nat.Port(ContainerPort(engine) + "/tcp")
+	containerConfig := &container.Config{
+		Image:        opts.Image,
+		Env:          engine.Env(),
+		Cmd:          engine.Cmd(),
+		ExposedPorts: nat.PortSet{port: {}},
+		Labels:       Labels(engine),
+	}
+	if e, ok := engine.(Entrypointer); ok {
+		containerConfig.Entrypoint = e.Entrypoint()
+	}
+
+	// Host configuration with port mapping and volume
+	hostConfig := &container.HostConfig{
+		PortBindings: nat.PortMap{port: portBindings(opts)},
+	}
+	if opts.Volume != "" {
+		hostConfig.Binds = []string{opts.Volume + ":" + engine.DataPath()}
+	}
+
+	var networkingConfig *network.NetworkingConfig
+	if opts.Network != "" {
+		networkingConfig = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				opts.Network: {},
+			},
+		}
+	}
+
+	id, err := backend.CreateContainer(ctx, opts.Name, containerConfig, hostConfig, networkingConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create %s container: %w", name, err)
+	}
+
+	if scripts != nil {
+		if err := backend.CopyToContainer(ctx, id, engine.(InitScripter).InitPath(), scripts); err != nil {
+			return fmt.Errorf("failed to copy init scripts: %w", err)
+		}
+	}
+	secretFiles, err := secretArchive(engine)
+	if err != nil {
+		return err
+	}
+	if secretFiles != nil {
+		if err := backend.CopyToContainer(ctx, id, path.Dir(SecretsPath), secretFiles); err != nil {
+			return fmt.Errorf("failed to copy password files: %w", err)
 		}
-		fmt.Printf("Successfully pulled image: %s\n", image)
+	}
+
+	if err := backend.StartContainer(ctx, id); err != nil {
+		return fmt.Errorf("failed to start %s container: %w", name, err)
+	}
+
+	err = WaitReady(ctx, backend, engine, id)
+	var exited *ExitError
+	if scripts != nil && errors.As(err, &exited) {
+		return initFailed(ctx, backend, engine, id, freshVolume, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := SaveCredentials(engine); err != nil {
+		fmt.Printf("Warning: failed to store the credentials of %s: %v\n", opts.Name, err)
 	}
 	return nil
 }
-func CreateNetworkWithCLI(name string) error {
-    if name == "" {
-        return nil // No network specified, skip creation
-    }
-    
-    // Check if network exists
-    checkCmd := exec.Command("docker", "network", "inspect", name)
-    if err := checkCmd.Run(); err == nil {
-        // Network exists
-        fmt.Printf("Network %s already exists\n", name)
-        return nil
-    }
-
-    // Network doesn't exist, create it
-    fmt.Printf("Creating network: %s...\n", name)
-    createCmd := exec.Command("docker", "network", "create", name)
-    output, err := createCmd.CombinedOutput()
-    if err != nil {
-        return fmt.Errorf("failed to create network: %v, output: %s", err, output)
-    }
-    fmt.Printf("Successfully created network: %s\n", name)
-    return nil
-}
\ No newline at end of file
+
+// Setup provisions engine against the local Docker daemon.
+func Setup(ctx context.Context, engine Engine) error {
+	backend, err := docker.NewDockerClient()
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+
+	return Provision(ctx, backend, engine)
+}
+
+// portBindings publishes the host port on the bind address and, when IPv6
+// is enabled, on its IPv6 counterpart as well
+func portBindings(opts *ContainerOptions) []nat.PortBinding {
+	bindIP := opts.BindIP
+	if bindIP == "" {
+		bindIP = DefaultBindIP
+	}
+	bindings := []nat.PortBinding{{HostIP: bindIP, HostPort: opts.HostPort}}
+	if !opts.IPv6 {
+		return bindings
+	}
+	switch bindIP {
+	case "127.0.0.1":
+		bindings = append(bindings, nat.PortBinding{HostIP: "::1", HostPort: opts.HostPort})
+	case "0.0.0.0":
+		bindings = append(bindings, nat.PortBinding{HostIP: "::", HostPort: opts.HostPort})
+	}
+	return bindings
+}
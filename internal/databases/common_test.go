@@ -0,0 +1,132 @@
+package databases
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"dockerdb/internal/secrets"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// useTempStore points the credential store at a temporary directory
+func useTempStore(t *testing.T) string {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	return filepath.Join(dir, "dockerdb")
+}
+
+func TestProvision(t *testing.T) {
+	tests := []struct {
+		name          string
+		network       string
+		existing      bool
+		initScript    bool
+		volumeExists  bool
+		exits         bool
+		wantErr       string
+		wantContainer bool
+		wantVolume    bool
+	}{
+		{name: "ready", wantContainer: true, wantVolume: true},
+		{name: "on a network", network: "app", wantContainer: true, wantVolume: true},
+		{name: "name in use", existing: true, wantErr: "failed to create PostgreSQL container", wantContainer: true},
+		{name: "exit without init scripts", exits: true, wantErr: "container pg exited with code 3", wantContainer: true, wantVolume: true},
+		{name: "init failure on a new volume", initScript: true, exits: true, wantErr: "removed container pg and volume pg_data"},
+		{name: "init failure on an existing volume", initScript: true, volumeExists: true, exits: true, wantErr: "removed container pg so the next attempt", wantVolume: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storeDir := useTempStore(t)
+			backend := newFakeBackend()
+			backend.exits = tt.exits
+			backend.exitCode = 3
+			if tt.volumeExists {
+				backend.volumes["pg_data"] = nil
+			}
+			if tt.existing {
+				if _, err := backend.CreateContainer(context.Background(), "pg", nil, &container.HostConfig{}, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			engine := NewPostgresConfig()
+			engine.Name = "pg"
+			engine.Volume = "pg_data"
+			engine.HostPort = AutoPort
+			engine.Password = "secret"
+			engine.Network = tt.network
+			engine.ReadyTimeout = 5 * time.Second
+			if tt.initScript {
+				script := filepath.Join(t.TempDir(), "seed.sql")
+				if err := os.WriteFile(script, []byte("SELECT 1;\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				engine.InitScripts = []string{script}
+			}
+
+			err := Provision(context.Background(), backend, engine)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Provision() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Provision() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+
+			info, err := backend.InspectContainer(context.Background(), "pg")
+			if (err == nil) != tt.wantContainer {
+				t.Fatalf("container exists = %v, want %v", err == nil, tt.wantContainer)
+			}
+			if exists, _ := backend.VolumeExists(context.Background(), "pg_data"); exists != tt.wantVolume {
+				t.Errorf("volume exists = %v, want %v", exists, tt.wantVolume)
+			}
+			_, stored, err := secrets.NewStore(storeDir).Get("pg")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored != (tt.wantErr == "") {
+				t.Errorf("credentials stored = %v, want %v", stored, tt.wantErr == "")
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if !info.State.Running {
+				t.Error("container is not running")
+			}
+			if got, want := info.HostConfig.Binds, []string{"pg_data:/var/lib/postgresql"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("Binds = %v, want %v", got, want)
+			}
+			if tt.network != "" && !backend.networks[tt.network] {
+				t.Errorf("network %s was not created", tt.network)
+			}
+		})
+	}
+}
+
+func TestProvisionUsesImageDataPath(t *testing.T) {
+	useTempStore(t)
+	backend := newFakeBackend()
+	engine := NewPostgresConfig()
+	engine.Password = "secret"
+	engine.HostPort = AutoPort
+	engine.SetTag("16")
+
+	if err := Provision(context.Background(), backend, engine); err != nil {
+		t.Fatal(err)
+	}
+	info, err := backend.InspectContainer(context.Background(), engine.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.HostConfig.Binds; len(got) != 1 || got[0] != "postgres_data:/var/lib/postgresql/data" {
+		t.Errorf("Binds = %v", got)
+	}
+	if got := dataVolume(info); got != "postgres_data" {
+		t.Errorf("dataVolume() = %q, want postgres_data", got)
+	}
+}
@@ -0,0 +1,79 @@
+package databases
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/go-connections/nat"
+)
+
+// FromContainer rebuilds the engine of an existing dockerdb container from
+// its labels, environment and port bindings
+func FromContainer(info types.ContainerJSON) (Engine, error) {
+	if info.Config == nil {
+		return nil, fmt.Errorf("container has no configuration")
+	}
+	engine, err := NewEngine(info.Config.Labels[LabelEngine])
+	if err != nil {
+		return nil, err
+	}
+
+	opts := engine.Options()
+	opts.Name = strings.TrimPrefix(info.Name, "/")
+	opts.Image = info.Config.Image
+	opts.ContainerPort = info.Config.Labels[LabelContainerPort]
+	opts.Topology = info.Config.Labels[LabelTopology]
+	opts.Role = info.Config.Labels[LabelRole]
+	opts.HostPort = ""
+	if info.HostConfig != nil {
+		port := nat.Port(ContainerPort(engine) + "/tcp")
+		opts.IPv6 = false
+		for i, binding := range info.HostConfig.PortBindings[port] {
+			if i == 0 {
+				opts.BindIP = binding.HostIP
+			} else if strings.Contains(binding.HostIP, ":") {
+				opts.IPv6 = true
+			}
+		}
+	}
+	if info.NetworkSettings != nil {
+		port := nat.Port(ContainerPort(engine) + "/tcp")
+		for _, binding := range info.NetworkSettings.Ports[port] {
+			if binding.HostPort != "" {
+				opts.HostPort = binding.HostPort
+				break
+			}
+		}
+		opts.Network = ""
+		for network := range info.NetworkSettings.Networks {
+			if network != "bridge" {
+				opts.Network = network
+				break
+			}
+		}
+	}
+	opts.Volume = dataVolume(info)
+
+	engine.Load(info.Config)
+	loadCredentials(opts.Name, engine)
+	return engine, nil
+}
+
+// envMap turns KEY=value pairs into a map
+func envMap(env []string) map[string]string {
+	values := make(map[string]string, len(env))
+	for _, pair := range env {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// loadEnv copies the value of key into *dst when the variable is set
+func loadEnv(env map[string]string, key string, dst *string) {
+	if value, ok := env[key]; ok {
+		*dst = value
+	}
+}
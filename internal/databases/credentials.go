@@ -0,0 +1,187 @@
+package databases
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"dockerdb/internal/docker"
+	"dockerdb/internal/secrets"
+
+	"github.com/docker/docker/api/types"
+)
+
+// PasswordChanger is implemented by engines that can change a user's
+// password while the database is running
+type PasswordChanger interface {
+	// MainUser returns the user whose password dockerdb hands out to clients.
+	MainUser() string
+	// PasswordCommand returns the command giving user a new password.
+	PasswordCommand(user, password string) (Command, error)
+	// SetPassword records a changed password on the configuration when it
+	// belongs to one of the users dockerdb tracks.
+	SetPassword(user, password string)
+}
+
+// SaveCredentials remembers the credentials of engine in the local
+// credential store, keyed by container name
+func SaveCredentials(engine Engine) error {
+	return saveCredentials(engine.Options().Name, engine)
+}
+
+// saveCredentials stores the credentials of engine under key, which is a
+// container name or a snapshot reference
+func saveCredentials(key string, engine Engine) error {
+	store, err := secrets.Open()
+	if err != nil {
+		return err
+	}
+	s := Current(engine)
+	return store.Put(key, secrets.Credentials{
+		Engine:       engine.Kind(),
+		User:         s.User,
+		Password:     s.Password,
+		RootPassword: s.RootPassword,
+	})
+}
+
+// ForgetCredentials removes the stored credentials of the named container
+// and reports whether there were any
+func ForgetCredentials(name string) (bool, error) {
+	store, err := secrets.Open()
+	if err != nil {
+		return false, err
+	}
+	return store.Delete(name)
+}
+
+// loadCredentials overrides the credentials read from a container with the
+// ones stored under key. Passwords kept in secret files are only known to
+// the store, and stored ones are newer after a password was rotated. An
+// unreadable store only warns, since commands such as rm do not need the
+// credentials and the others report failed logins themselves.
+func loadCredentials(key string, engine Engine) {
+	store, err := secrets.Open()
+	if err == nil {
+		var creds secrets.Credentials
+		var ok bool
+		creds, ok, err = store.Get(key)
+		if err == nil && ok && creds.Engine == engine.Kind() {
+			Apply(engine, Settings{
+				User:         creds.User,
+				Password:     creds.Password,
+				RootPassword: creds.RootPassword,
+			})
+		}
+	}
+	if err != nil {
+		// stderr, so that connect-info output stays usable in .env files
+		fmt.Fprintf(os.Stderr, "Warning: the stored credentials of %s cannot be read: %v\n", key, err)
+	}
+}
+
+// MainUser returns the user whose password dockerdb hands out to clients
+func MainUser(engine Engine) string {
+	if changer, ok := engine.(PasswordChanger); ok {
+		return changer.MainUser()
+	}
+	return Current(engine).User
+}
+
+// RotatePassword gives user, or the main user when empty, a new password
+// inside the running named container. The container's password files are
+// rewritten so the change survives restarts, and the stored credentials are
+// updated. It returns the engine with the new password applied.
+func RotatePassword(ctx context.Context, backend Backend, name, user, password string) (Engine, error) {
+	engine, info, err := runningEngine(ctx, backend, name)
+	if err != nil {
+		return nil, err
+	}
+	changer, ok := engine.(PasswordChanger)
+	if !ok {
+		return nil, fmt.Errorf("%s does not support changing passwords", engine.DisplayName())
+	}
+	// Members of a topology share their passwords: replicas authenticate to
+	// their primary with them, and a replica set or cluster replicates its
+	// users, so changing one on a single member breaks or desyncs the others
+	if topology := engine.Options().Topology; topology != "" {
+		return nil, fmt.Errorf("%s belongs to the %s topology %s, whose members share their passwords; recreate the topology to change them", name, engine.DisplayName(), topology)
+	}
+	if user == "" {
+		user = changer.MainUser()
+	}
+
+	command, err := changer.PasswordCommand(user, password)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := runCommand(ctx, backend, info.ID, command); err != nil {
+		return nil, fmt.Errorf("failed to change the password of %s: %w", user, err)
+	}
+
+	changer.SetPassword(user, password)
+	if err := persistSecrets(ctx, backend, info, engine); err != nil {
+		return nil, err
+	}
+	if err := SaveCredentials(engine); err != nil {
+		return nil, fmt.Errorf("password changed but not stored: %w", err)
+	}
+	return engine, nil
+}
+
+// persistSecrets rewrites the password files of a running container after a
+// password change. Redis containers with an ACL file save their users to it;
+// those started without a password file, i.e. without a password or before
+// passwords moved to a file, cannot be updated and lose the change when they
+// restart.
+func persistSecrets(ctx context.Context, backend Backend, info types.ContainerJSON, engine Engine) error {
+	if redis, ok := engine.(*RedisConfig); ok {
+		if redis.usesACLFile() {
+			return redis.saveACL(ctx, backend, info.ID)
+		}
+		if !hasArg(info.Config.Cmd, secretFile(redisConfigFile)) {
+			fmt.Printf("Warning: %s does not read its password from a file; the old password returns when it restarts\n", redis.Name)
+			return nil
+		}
+	}
+	archive, err := secretArchive(engine)
+	if err != nil || archive == nil {
+		return err
+	}
+	if err := backend.CopyToContainer(ctx, info.ID, path.Dir(SecretsPath), archive); err != nil {
+		return fmt.Errorf("password changed but the password files were not updated: %w", err)
+	}
+	return nil
+}
+
+// hasArg reports whether arg is one of args
+func hasArg(args []string, arg string) bool {
+	for _, a := range args {
+		if a == arg {
+			return true
+		}
+	}
+	return false
+}
+
+// runCommand executes command inside a running container and returns its
+// output, failing with the command's error output on a non-zero exit code
+func runCommand(ctx context.Context, backend Backend, containerID string, command Command) (string, error) {
+	var stdout, stderr bytes.Buffer
+	code, err := backend.Exec(ctx, containerID, docker.ExecOptions{
+		Cmd:    command.Cmd,
+		Env:    command.Env,
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		return "", err
+	}
+	if code != 0 {
+		return "", fmt.Errorf("command exited with code %d: %s", code, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
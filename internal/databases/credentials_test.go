@@ -0,0 +1,47 @@
+package databases
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRotatePassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		engine   Engine
+		topology string
+		wantErr  string
+	}{
+		{name: "standalone", engine: NewPostgresConfig()},
+		{name: "postgres replica", engine: NewPostgresConfig(), topology: "pg", wantErr: "belongs to the PostgreSQL topology pg"},
+		{name: "mysql primary", engine: NewMySQLConfig(), topology: "mysql", wantErr: "belongs to the MySQL topology mysql"},
+		{name: "mongodb member", engine: NewMongoDBConfig(), topology: "rs", wantErr: "belongs to the MongoDB topology rs"},
+		{name: "redis member", engine: NewRedisConfig(), topology: "cache", wantErr: "belongs to the Redis topology cache"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempStore(t)
+			backend := newFakeBackend()
+			opts := tt.engine.Options()
+			opts.Name = "db"
+			opts.Volume = "db_data"
+			opts.Topology = tt.topology
+			addContainer(t, backend, tt.engine, true)
+
+			engine, err := RotatePassword(context.Background(), backend, "db", "", "rotated")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RotatePassword() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RotatePassword() error = %v", err)
+			}
+			if got := Current(engine).Password; got != "rotated" {
+				t.Errorf("password = %q, want rotated", got)
+			}
+		})
+	}
+}
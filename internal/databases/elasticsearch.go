@@ -0,0 +1,123 @@
+package databases
+
+import (
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// ElasticsearchConfig holds configuration for a single-node Elasticsearch
+// container. With a password, security is enabled over plain HTTP and the
+// elastic superuser logs in with it; without one, security is disabled.
+type ElasticsearchConfig struct {
+	ContainerOptions
+	Password string
+}
+
+// elasticPasswordFile holds the password read through ELASTIC_PASSWORD_FILE
+const elasticPasswordFile = "elastic_password"
+
+// elasticUser is the built-in superuser whose password the image sets
+const elasticUser = "elastic"
+
+// ElasticsearchTag is the default image tag; the image has no latest tag
+const ElasticsearchTag = "9.1.0"
+
+// searchTimeout is how long Elasticsearch and OpenSearch may take to start
+const searchTimeout = 120 * time.Second
+
+// NewElasticsearchConfig returns a default Elasticsearch configuration
+func NewElasticsearchConfig() *ElasticsearchConfig {
+	return &ElasticsearchConfig{
+		ContainerOptions: ContainerOptions{
+			Name:     "elasticsearch",
+			Image:    "elasticsearch:" + ElasticsearchTag,
+			HostPort: "9200",
+			BindIP:   DefaultBindIP,
+			Volume:   "elasticsearch_data",
+		},
+	}
+}
+
+func (c *ElasticsearchConfig) Kind() string        { return "elasticsearch" }
+func (c *ElasticsearchConfig) DisplayName() string { return "Elasticsearch" }
+func (c *ElasticsearchConfig) DefaultPort() string { return "9200" }
+func (c *ElasticsearchConfig) DataPath() string    { return "/usr/share/elasticsearch/data" }
+func (c *ElasticsearchConfig) Cmd() []string       { return nil }
+
+// Env configures a single node through settings passed as environment
+// variables, which the image understands alongside its own
+func (c *ElasticsearchConfig) Env() []string {
+	env := []string{"discovery.type=single-node"}
+	if c.Password == "" {
+		env = append(env, "xpack.security.enabled=false")
+	} else {
+		env = append(env,
+			"ELASTIC_PASSWORD_FILE="+secretFile(elasticPasswordFile),
+			"xpack.security.http.ssl.enabled=false")
+	}
+	if port := ContainerPort(c); port != c.DefaultPort() {
+		env = append(env, "http.port="+port)
+	}
+	return env
+}
+
+// SecretFiles returns the password referenced by ELASTIC_PASSWORD_FILE
+func (c *ElasticsearchConfig) SecretFiles() map[string]string {
+	if c.Password == "" {
+		return nil
+	}
+	return map[string]string{elasticPasswordFile: c.Password}
+}
+
+// ReadyCheck waits for the cluster health to reach yellow
+func (c *ElasticsearchConfig) ReadyCheck() ReadyCheck {
+	return searchReadyCheck(c.ConnectionInfo(), ContainerPort(c))
+}
+
+// ConnectionInfo returns the HTTP endpoint of the node
+func (c *ElasticsearchConfig) ConnectionInfo() ConnectionInfo {
+	info := ConnectionInfo{
+		Scheme: "http",
+		Host:   c.ConnectHost(),
+		Port:   c.HostPort,
+	}
+	if c.Password != "" {
+		info.User = elasticUser
+		info.Password = c.Password
+	}
+	return info
+}
+
+// Load has nothing to restore, since the password is only kept in a secret
+// file and the credential store
+func (c *ElasticsearchConfig) Load(config *container.Config) {}
+
+// Settings returns the settings that Configure changes
+func (c *ElasticsearchConfig) Settings() Settings {
+	return Settings{User: c.ConnectionInfo().User, Password: c.Password}
+}
+
+// Configure copies the non-empty settings onto the configuration
+func (c *ElasticsearchConfig) Configure(s Settings) {
+	override(&c.Password, s.Password)
+}
+
+// RequiresPassword reports whether the image refuses to start without a
+// password
+func (c *ElasticsearchConfig) RequiresPassword() bool { return false }
+
+// searchReadyCheck asks the REST API of Elasticsearch or OpenSearch inside
+// the container for a cluster health of at least yellow. The credentials
+// are passed in the environment to keep them off the command line.
+func searchReadyCheck(info ConnectionInfo, port string) ReadyCheck {
+	url := info.Scheme + "://127.0.0.1:" + port + "/_cluster/health?wait_for_status=yellow&timeout=1s"
+	check := ReadyCheck{
+		Command: []string{"sh", "-c", `curl --silent --show-error --fail --insecure ${AUTH:+--user "$AUTH"} "$1" >/dev/null`, "sh", url},
+		Timeout: searchTimeout,
+	}
+	if info.User != "" {
+		check.Env = []string{"AUTH=" + info.User + ":" + info.Password}
+	}
+	return check
+}
@@ -0,0 +1,140 @@
+package databases
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sqlInitExtensions are the init scripts run by the MySQL, MariaDB and
+// PostgreSQL images
+var sqlInitExtensions = []string{".sh", ".sql", ".sql.gz", ".sql.xz", ".sql.zst"}
+
+// initScriptArchive packs every configured init script into a tar archive
+// to be extracted into the engine's init directory before the first start.
+// Directories contribute the scripts they contain, since the images do not
+// descend into subdirectories. It returns nil when no scripts are configured.
+func initScriptArchive(engine Engine) (*bytes.Buffer, error) {
+	scripts := engine.Options().InitScripts
+	if len(scripts) == 0 {
+		return nil, nil
+	}
+	scripter, ok := engine.(InitScripter)
+	if !ok {
+		return nil, fmt.Errorf("%s does not support init scripts", engine.DisplayName())
+	}
+	extensions := scripter.InitExtensions()
+
+	var files []string
+	for _, script := range scripts {
+		info, err := os.Stat(script)
+		if err != nil {
+			return nil, fmt.Errorf("init script: %w", err)
+		}
+		if !info.IsDir() {
+			if !hasExtension(script, extensions) {
+				return nil, fmt.Errorf("init script %s: %s only runs %s files",
+					script, engine.DisplayName(), strings.Join(extensions, ", "))
+			}
+			files = append(files, script)
+			continue
+		}
+
+		entries, err := os.ReadDir(script)
+		if err != nil {
+			return nil, fmt.Errorf("init script: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			if !hasExtension(entry.Name(), extensions) {
+				fmt.Printf("Skipping %s: not a %s init script\n", filepath.Join(script, entry.Name()), engine.DisplayName())
+				continue
+			}
+			files = append(files, filepath.Join(script, entry.Name()))
+		}
+	}
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	seen := make(map[string]string)
+	for _, file := range files {
+		name := filepath.Base(file)
+		if previous, ok := seen[name]; ok {
+			return nil, fmt.Errorf("init scripts %s and %s have the same file name", previous, file)
+		}
+		seen[name] = file
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("init script: %w", err)
+		}
+		mode := int64(0o644)
+		if strings.HasSuffix(name, ".sh") {
+			mode = 0o755
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: mode, Size: int64(len(data))}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, err
+		}
+		fmt.Printf("Adding init script %s\n", file)
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return &archive, nil
+}
+
+// hasExtension reports whether name ends with one of the extensions
+func hasExtension(name string, extensions []string) bool {
+	for _, ext := range extensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkInitVolume warns when init scripts are configured for a volume that
+// already exists, since the images only run them on an empty data directory.
+// It reports whether the volume will be created fresh.
+func checkInitVolume(ctx context.Context, backend Backend, engine Engine) (bool, error) {
+	opts := engine.Options()
+	if len(opts.InitScripts) == 0 || opts.Volume == "" {
+		return false, nil
+	}
+	exists, err := backend.VolumeExists(ctx, opts.Volume)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		fmt.Printf("Warning: volume %s already exists; init scripts only run when it is empty\n", opts.Volume)
+	}
+	return !exists, nil
+}
+
+// initFailed removes the container, and the volume if it was created for
+// it, after an init script made the container exit. Otherwise the next
+// start would find a data directory and skip initialization, leaving a
+// half-initialized database behind.
+func initFailed(ctx context.Context, backend Backend, engine Engine, containerID string, freshVolume bool, cause error) error {
+	opts := engine.Options()
+	if err := backend.RemoveContainer(ctx, containerID); err != nil {
+		return fmt.Errorf("init scripts failed: %w (cleanup failed: %v)", cause, err)
+	}
+	removed := "container " + opts.Name
+	if freshVolume {
+		if err := backend.RemoveVolume(ctx, opts.Volume); err != nil {
+			return fmt.Errorf("init scripts failed: %w (cleanup failed: %v)", cause, err)
+		}
+		removed += " and volume " + opts.Volume
+	}
+	return fmt.Errorf("init scripts failed, removed %s so the next attempt starts clean: %w", removed, cause)
+}
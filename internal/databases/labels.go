@@ -0,0 +1,54 @@
+package databases
+
+import (
+	"strings"
+	"time"
+
+	"dockerdb/internal/version"
+)
+
+// Labels set on every container created by dockerdb
+const (
+	LabelManaged       = "io.dockerdb.managed"
+	LabelEngine        = "io.dockerdb.engine"
+	LabelVersion       = "io.dockerdb.engine-version"
+	LabelDockerDB      = "io.dockerdb.version"
+	LabelCreated       = "io.dockerdb.created"
+	LabelContainerPort = "io.dockerdb.container-port"
+	LabelTopology      = "io.dockerdb.topology"
+	LabelRole          = "io.dockerdb.role"
+)
+
+// Labels set on snapshot volumes
+const (
+	LabelSnapshot        = "io.dockerdb.snapshot"
+	LabelSnapshotSource  = "io.dockerdb.snapshot-source"
+	LabelSnapshotImage   = "io.dockerdb.snapshot-image"
+	LabelSnapshotEnv     = "io.dockerdb.snapshot-env"
+	LabelSnapshotCommand = "io.dockerdb.snapshot-cmd"
+)
+
+// Labels returns the labels identifying a container created for engine
+func Labels(engine Engine) map[string]string {
+	labels := map[string]string{
+		LabelManaged:       "true",
+		LabelEngine:        engine.Kind(),
+		LabelVersion:       imageTag(engine.Options().Image),
+		LabelDockerDB:      version.Version,
+		LabelCreated:       time.Now().UTC().Format(time.RFC3339),
+		LabelContainerPort: ContainerPort(engine),
+	}
+	if opts := engine.Options(); opts.Topology != "" {
+		labels[LabelTopology] = opts.Topology
+		labels[LabelRole] = opts.Role
+	}
+	return labels
+}
+
+// imageTag returns the tag of an image reference, defaulting to "latest"
+func imageTag(image string) string {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return "latest"
+}
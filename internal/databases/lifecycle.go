@@ -0,0 +1,126 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// ErrNotManaged is returned for containers that were not created by dockerdb
+var ErrNotManaged = errors.New("container is not managed by dockerdb")
+
+// Inspect returns the details of a container created by dockerdb
+func Inspect(ctx context.Context, backend Backend, name string) (types.ContainerJSON, error) {
+	info, err := backend.InspectContainer(ctx, name)
+	if err != nil {
+		return info, fmt.Errorf("failed to inspect %s: %w", name, err)
+	}
+	if info.Config == nil || info.Config.Labels[LabelManaged] != "true" {
+		return info, fmt.Errorf("%s: %w", name, ErrNotManaged)
+	}
+	return info, nil
+}
+
+// Start starts a stopped dockerdb container
+func Start(ctx context.Context, backend Backend, name string) error {
+	if _, err := Inspect(ctx, backend, name); err != nil {
+		return err
+	}
+	return backend.StartContainer(ctx, name)
+}
+
+// Stop stops a running dockerdb container
+func Stop(ctx context.Context, backend Backend, name string) error {
+	if _, err := Inspect(ctx, backend, name); err != nil {
+		return err
+	}
+	return backend.StopContainer(ctx, name)
+}
+
+// Restart restarts a dockerdb container
+func Restart(ctx context.Context, backend Backend, name string) error {
+	if _, err := Inspect(ctx, backend, name); err != nil {
+		return err
+	}
+	return backend.RestartContainer(ctx, name)
+}
+
+// Remove stops and removes a dockerdb container. With removeData it also
+// deletes the container's data volume and network once no other container
+// uses them.
+func Remove(ctx context.Context, backend Backend, name string, removeData bool) error {
+	info, err := Inspect(ctx, backend, name)
+	if err != nil {
+		return err
+	}
+
+	if info.State != nil && info.State.Running {
+		if err := backend.StopContainer(ctx, name); err != nil {
+			return err
+		}
+	}
+	if err := backend.RemoveContainer(ctx, name); err != nil {
+		return err
+	}
+	// The container is gone either way, so its volume and network are still
+	// cleaned up when the credential store cannot be updated
+	if _, err := ForgetCredentials(name); err != nil {
+		fmt.Printf("Warning: failed to forget the stored credentials of %s: %v\n", name, err)
+	}
+	if !removeData {
+		return nil
+	}
+
+	if volume := dataVolume(info); volume != "" {
+		if err := removeUnused(ctx, backend, "volume", volume, backend.RemoveVolume); err != nil {
+			return err
+		}
+	}
+	if info.NetworkSettings != nil {
+		for network := range info.NetworkSettings.Networks {
+			if network == "bridge" || network == "host" || network == "none" {
+				continue
+			}
+			if err := removeUnused(ctx, backend, "network", network, backend.RemoveNetwork); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dataVolume returns the named volume mounted at the engine's data path
+func dataVolume(info types.ContainerJSON) string {
+	engine, err := NewEngine(info.Config.Labels[LabelEngine])
+	if err != nil {
+		return ""
+	}
+	// The data path of some engines depends on the image version
+	engine.Options().Image = info.Config.Image
+	for _, m := range info.Mounts {
+		if m.Type == "volume" && m.Destination == engine.DataPath() {
+			return m.Name
+		}
+	}
+	return ""
+}
+
+// removeUnused removes a volume or network unless another container still uses it
+func removeUnused(ctx context.Context, backend Backend, kind, name string, remove func(context.Context, string) error) error {
+	users, err := backend.ListContainers(ctx, filters.NewArgs(filters.Arg(kind, name)))
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		fmt.Printf("Keeping %s %s: still used by %d container(s)\n", kind, name, len(users))
+		return nil
+	}
+	if err := remove(ctx, name); err != nil {
+		return err
+	}
+	fmt.Printf("Removed %s %s\n", kind, name)
+	return nil
+}
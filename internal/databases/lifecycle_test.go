@@ -0,0 +1,103 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dockerdb/internal/secrets"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+)
+
+// addContainer creates and starts the container of engine in backend
+// without waiting for it, labelled as dockerdb would when managed is set
+func addContainer(t *testing.T, backend *fakeBackend, engine Engine, managed bool) {
+	t.Helper()
+	opts := engine.Options()
+	config := &container.Config{Image: opts.Image}
+	if managed {
+		config.Labels = Labels(engine)
+	}
+	hostConfig := &container.HostConfig{Binds: []string{opts.Volume + ":" + engine.DataPath()}}
+	var networking *network.NetworkingConfig
+	if opts.Network != "" {
+		backend.networks[opts.Network] = true
+		networking = &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{opts.Network: {}}}
+	}
+	id, err := backend.CreateContainer(context.Background(), opts.Name, config, hostConfig, networking)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.StartContainer(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	tests := []struct {
+		name        string
+		removeData  bool
+		unmanaged   bool
+		shared      bool
+		brokenStore bool
+		wantErr     error
+		wantVolume  bool
+		wantNetwork bool
+	}{
+		{name: "keep data", wantVolume: true, wantNetwork: true},
+		{name: "remove data", removeData: true},
+		{name: "data used by another container", removeData: true, shared: true, wantVolume: true, wantNetwork: true},
+		{name: "unreadable credential store", removeData: true, brokenStore: true},
+		{name: "not managed", unmanaged: true, wantErr: ErrNotManaged, wantVolume: true, wantNetwork: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storeDir := useTempStore(t)
+			backend := newFakeBackend()
+			engine := NewRedisConfig()
+			engine.Name = "cache"
+			engine.Volume = "cache_data"
+			engine.Network = "app"
+			addContainer(t, backend, engine, !tt.unmanaged)
+			if err := SaveCredentials(engine); err != nil {
+				t.Fatal(err)
+			}
+			if tt.shared {
+				other := NewRedisConfig()
+				other.Name = "other"
+				other.Volume = "cache_data"
+				other.Network = "app"
+				addContainer(t, backend, other, true)
+			}
+			if tt.brokenStore {
+				if err := os.WriteFile(filepath.Join(storeDir, "credentials.enc"), []byte("damaged"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := Remove(context.Background(), backend, "cache", tt.removeData)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Remove() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := backend.container("cache"); (err == nil) != (tt.wantErr != nil) {
+				t.Errorf("container exists = %v", err == nil)
+			}
+			if _, ok := backend.volumes["cache_data"]; ok != tt.wantVolume {
+				t.Errorf("volume exists = %v, want %v", ok, tt.wantVolume)
+			}
+			if ok := backend.networks["app"]; ok != tt.wantNetwork {
+				t.Errorf("network exists = %v, want %v", ok, tt.wantNetwork)
+			}
+			if tt.brokenStore {
+				return
+			}
+			if _, stored, _ := secrets.NewStore(storeDir).Get("cache"); stored != (tt.wantErr != nil) {
+				t.Errorf("credentials stored = %v, want %v", stored, tt.wantErr != nil)
+			}
+		})
+	}
+}
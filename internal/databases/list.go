@@ -0,0 +1,89 @@
+package databases
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// Instance describes a container created by dockerdb
+type Instance struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Engine   string `json:"engine"`
+	Version  string `json:"version"`
+	State    string `json:"state"`
+	Status   string `json:"status"`
+	HostPort string `json:"host_port,omitempty"`
+	Volume   string `json:"volume,omitempty"`
+	Networks string `json:"networks,omitempty"`
+	Created  string `json:"created"`
+}
+
+// List returns every container created by dockerdb, sorted by name
+func List(ctx context.Context, backend Backend) ([]Instance, error) {
+	containers, err := backend.ListContainers(ctx, filters.NewArgs(filters.Arg("label", LabelManaged+"=true")))
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]Instance, 0, len(containers))
+	for _, c := range containers {
+		instances = append(instances, newInstance(c))
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].Name < instances[j].Name
+	})
+	return instances, nil
+}
+
+// newInstance summarizes a container using its dockerdb labels
+func newInstance(c types.Container) Instance {
+	instance := Instance{
+		ID:      c.ID,
+		Engine:  c.Labels[LabelEngine],
+		Version: c.Labels[LabelVersion],
+		State:   c.State,
+		Status:  c.Status,
+		Created: c.Labels[LabelCreated],
+	}
+	if len(c.Names) > 0 {
+		instance.Name = strings.TrimPrefix(c.Names[0], "/")
+	}
+
+	var containerPort, dataPath string
+	if engine, err := NewEngine(instance.Engine); err == nil {
+		containerPort = engine.DefaultPort()
+		if port := c.Labels[LabelContainerPort]; port != "" {
+			containerPort = port
+		}
+		// The data path of some engines depends on the image version
+		engine.Options().Image = c.Image
+		dataPath = engine.DataPath()
+	}
+	for _, p := range c.Ports {
+		if p.PublicPort != 0 && strconv.Itoa(int(p.PrivatePort)) == containerPort {
+			instance.HostPort = strconv.Itoa(int(p.PublicPort))
+			break
+		}
+	}
+	for _, m := range c.Mounts {
+		if m.Type == "volume" && m.Destination == dataPath {
+			instance.Volume = m.Name
+			break
+		}
+	}
+	if c.NetworkSettings != nil {
+		var networks []string
+		for name := range c.NetworkSettings.Networks {
+			networks = append(networks, name)
+		}
+		sort.Strings(networks)
+		instance.Networks = strings.Join(networks, ",")
+	}
+	return instance
+}
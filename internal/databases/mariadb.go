@@ -2,149 +2,277 @@ package databases
 
 import (
 	"context"
-	"fmt"
+	"net"
 	"time"
 
-	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/client"
-	"github.com/docker/go-connections/nat"
 )
 
 // MariaDBConfig holds configuration for a MariaDB container
 type MariaDBConfig struct {
-	Name         string
-	Image        string
-	Port         string
+	ContainerOptions
 	RootPassword string
 	DatabaseName string
 	User         string
 	Password     string
-	Volume       string
-	Network  	 string
+	Replication  Replication
 }
 
 // NewMariaDBConfig returns a default MariaDB configuration
 func NewMariaDBConfig() *MariaDBConfig {
 	return &MariaDBConfig{
-		Name:         "mariadb-db",
-		Image:        "mariadb:latest",
-		Port:         "3306",
+		ContainerOptions: ContainerOptions{
+			Name:     "mariadb-db",
+			Image:    "mariadb:latest",
+			HostPort: "3306",
+			BindIP:   DefaultBindIP,
+			Volume:   "mariadb_data",
+		},
 		DatabaseName: "mydb",
-		Volume:       "mariadb_data",
 	}
 }
 
-// SetupMariaDBContainer creates and starts a MariaDB container
-func SetupMariaDBContainer(config MariaDBConfig) error {
-	ctx := context.Background()
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithVersion("1.40"))
-	if err != nil {
-		return fmt.Errorf("failed to create Docker client: %w", err)
+func (c *MariaDBConfig) Kind() string             { return "mariadb" }
+func (c *MariaDBConfig) DisplayName() string      { return "MariaDB" }
+func (c *MariaDBConfig) DefaultPort() string      { return "3306" }
+func (c *MariaDBConfig) DataPath() string         { return "/var/lib/mysql" }
+func (c *MariaDBConfig) InitPath() string         { return "/docker-entrypoint-initdb.d" }
+func (c *MariaDBConfig) InitExtensions() []string { return sqlInitExtensions }
+
+// Cmd moves the server to a custom container port if one was chosen and
+// enables the binary log on primaries and replicas. MariaDB always tracks
+// GTIDs.
+func (c *MariaDBConfig) Cmd() []string {
+	var args []string
+	if port := ContainerPort(c); port != c.DefaultPort() {
+		args = append(args, "--port="+port)
 	}
-	if err := PullImageIfNotExists(ctx, cli, config.Image); err != nil {
-		return fmt.Errorf("failed to ensure MariaDB image: %w", err)
+	return append(args, mysqlReplicationArgs(c.Replication, &c.ContainerOptions, ContainerPort(c))...)
+}
+
+// mariadbReplicaScript makes a new replica skip the transactions its
+// primary ran before the replica existed, which the replica's own
+// initialization from the same settings already applied, and starts
+// replicating from the primary's GTID position. It is called with the
+// primary's host, port and replication user; MYSQL_PWD holds the root
+// password and REPLICATION_PASSWORD the replication user's.
+const mariadbReplicaScript = `set -e
+client=$(command -v mariadb || command -v mysql)
+gtids=$(MYSQL_PWD="$REPLICATION_PASSWORD" "$client" --host="$1" --port="$2" --user="$3" --batch --skip-column-names \
+	--execute='SELECT @@GLOBAL.gtid_binlog_pos')
+"$client" --user=root --execute="STOP SLAVE; SET GLOBAL gtid_slave_pos = '$gtids';
+CHANGE MASTER TO MASTER_HOST = '$1', MASTER_PORT = $2, MASTER_USER = '$3',
+	MASTER_PASSWORD = '$REPLICATION_PASSWORD', MASTER_USE_GTID = slave_pos;
+START SLAVE;"`
+
+// Env returns the environment variables understood by the MariaDB image
+func (c *MariaDBConfig) Env() []string {
+	env := []string{
+		"MARIADB_ROOT_PASSWORD_FILE=" + secretFile("mariadb_root_password"),
+		"MARIADB_DATABASE=" + c.DatabaseName,
 	}
-	    // Create network if specified
-	if config.Network != "" {
-		// Check if network exists
-		networks, err := cli.NetworkList(ctx, types.NetworkListOptions{})
-		if err != nil {
-			return fmt.Errorf("failed to list networks: %w", err)
-		}
-
-		networkExists := false
-		for _, network := range networks {
-			if network.Name == config.Network {
-				networkExists = true
-				break
-			}
-		}
-
-		if !networkExists {
-			fmt.Printf("Creating network: %s...\n", config.Network)
-			_, err = cli.NetworkCreate(ctx, config.Network, types.NetworkCreate{})
-			if err != nil {
-				return fmt.Errorf("failed to create network: %w", err)
-			}
-			fmt.Printf("Successfully created network: %s\n", config.Network)
-		} else {
-			fmt.Printf("Network %s already exists\n", config.Network)
-		}
+	if c.User != "" && c.Password != "" {
+		env = append(env, "MARIADB_USER="+c.User)
+		env = append(env, "MARIADB_PASSWORD_FILE="+secretFile("mariadb_password"))
 	}
+	return env
+}
 
-	// Environment variables for MariaDB
-	env := []string{
-		"MARIADB_ROOT_PASSWORD=" + config.RootPassword,
-		"MARIADB_DATABASE=" + config.DatabaseName,
+// SecretFiles returns the passwords referenced by the *_FILE variables
+func (c *MariaDBConfig) SecretFiles() map[string]string {
+	files := map[string]string{"mariadb_root_password": c.RootPassword}
+	if c.User != "" && c.Password != "" {
+		files["mariadb_password"] = c.Password
 	}
+	return files
+}
 
-	if config.User != "" && config.Password != "" {
-		env = append(env, "MARIADB_USER="+config.User)
-		env = append(env, "MARIADB_PASSWORD="+config.Password)
+// ReadyCheck pings the server over TCP, which only succeeds once the
+// temporary server used during initialization has been replaced. Recent
+// images only ship mariadb-admin, older ones only mysqladmin.
+func (c *MariaDBConfig) ReadyCheck() ReadyCheck {
+	return ReadyCheck{
+		Command: []string{"sh", "-c", `admin=$(command -v mariadb-admin || command -v mysqladmin) && ` +
+			`"$admin" ping --host=127.0.0.1 --protocol=tcp --port=` + ContainerPort(c) + ` --user=root --silent`},
+		Env:     []string{"MYSQL_PWD=" + c.RootPassword},
+		Timeout: 90 * time.Second,
 	}
+}
 
-	// Prepare container configuration
-	containerConfig := &container.Config{
-		Image: config.Image,
-		Env:   env,
-		ExposedPorts: map[nat.Port]struct{}{
-			nat.Port(config.Port + "/tcp"): {},
-		},
+// ConnectionInfo returns the details needed to connect to MariaDB
+func (c *MariaDBConfig) ConnectionInfo() ConnectionInfo {
+	return ConnectionInfo{
+		Scheme:   "mysql",
+		Host:     c.ConnectHost(),
+		Port:     c.HostPort,
+		Database: c.DatabaseName,
+		User:     c.User,
+		Password: c.Password,
 	}
+}
 
-	// Host configuration with port mapping and volume
-	hostConfig := &container.HostConfig{
-		PortBindings: nat.PortMap{
-			nat.Port(config.Port + "/tcp"): []nat.PortBinding{
-				{
-					HostIP:   "0.0.0.0",
-					HostPort: config.Port,
-				},
-			},
-		},
-		Binds: []string{config.Volume + ":/var/lib/mysql"},
+// ShellCommand opens the mariadb client as the application user, or as root
+// when no user was configured. Older images only ship the mysql client.
+func (c *MariaDBConfig) ShellCommand() Command {
+	user, password := c.User, c.Password
+	if user == "" || password == "" {
+		user, password = "root", c.RootPassword
+	}
+	return Command{
+		Cmd: []string{"sh", "-c", `exec "$(command -v mariadb || command -v mysql)" "$@"`, "sh",
+			"--user=" + user, c.DatabaseName},
+		Env: []string{"MYSQL_PWD=" + password},
 	}
+}
+
+// BackupCommand dumps the configured database with mariadb-dump, falling
+// back to mysqldump on older images
+func (c *MariaDBConfig) BackupCommand() Command {
+	return Command{
+		Cmd: []string{"sh", "-c", `exec "$(command -v mariadb-dump || command -v mysqldump)" "$@"`, "sh",
+			"--user=root", "--single-transaction", "--routines", "--triggers", "--events",
+			"--databases", c.DatabaseName},
+		Env: []string{"MYSQL_PWD=" + c.RootPassword},
+	}
+}
 
-	    // Network config
-		var networkingConfig *network.NetworkingConfig
-		if config.Network != "" {
-			networkingConfig = &network.NetworkingConfig{
-				EndpointsConfig: map[string]*network.EndpointSettings{
-					config.Network: {},
-				},
-			}
-		}
-	
-
-	// Create container
-	resp, err := cli.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, config.Name)
-	if err != nil {
-		return fmt.Errorf("failed to create MariaDB container: %w", err)
+func (c *MariaDBConfig) BackupFormat() string { return "sql" }
+
+// RestoreCommand loads an SQL dump with the mariadb client, falling back to
+// mysql on older images
+func (c *MariaDBConfig) RestoreCommand(format string) (Command, error) {
+	if format != "sql" {
+		return Command{}, unsupportedFormat(c, format)
 	}
+	return Command{
+		Cmd: []string{"sh", "-c", `exec "$(command -v mariadb || command -v mysql)" "$@"`, "sh",
+			"--user=root", c.DatabaseName},
+		Env: []string{"MYSQL_PWD=" + c.RootPassword},
+	}, nil
+}
 
-	// Start the container
-	if err := cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
-		return fmt.Errorf("failed to start MariaDB container: %w", err)
+// rootCommand runs SQL statements as root, printing bare result rows
+func (c *MariaDBConfig) rootCommand(sql string) Command {
+	return Command{
+		Cmd: []string{"sh", "-c", `exec "$(command -v mariadb || command -v mysql)" "$@"`, "sh",
+			"--user=root", "--batch", "--skip-column-names", "--execute=" + sql},
+		Env: []string{"MYSQL_PWD=" + c.RootPassword},
 	}
+}
+
+// MainUser returns the application user, or root when there is none
+func (c *MariaDBConfig) MainUser() string {
+	if c.User == "" || c.Password == "" {
+		return "root"
+	}
+	return c.User
+}
+
+// SetPassword records a changed password of root or the application user
+func (c *MariaDBConfig) SetPassword(user, password string) {
+	if user == "root" {
+		c.RootPassword = password
+	} else if user == c.User {
+		c.Password = password
+	}
+}
+
+// PasswordCommand changes a user's password with ALTER USER as root
+func (c *MariaDBConfig) PasswordCommand(user, password string) (Command, error) {
+	return c.rootCommand(mysqlAlterPassword(user, password)), nil
+}
 
-	// Wait for the container to be ready
-	timeout := time.After(30 * time.Second)
-	tick := time.Tick(1 * time.Second)
-
-	for {
-		select {
-		case <-timeout:
-			return fmt.Errorf("timeout waiting for MariaDB container to be ready")
-		case <-tick:
-			inspect, err := cli.ContainerInspect(ctx, resp.ID)
-			if err != nil {
-				return fmt.Errorf("failed to inspect MariaDB container: %w", err)
-			}
-			if inspect.State.Running {
-				fmt.Println("MariaDB container is running!")
-				return nil
-			}
-		}
+// AddUserCommand creates a user with its role on the configured database,
+// or on the one the user names
+func (c *MariaDBConfig) AddUserCommand(user User) (Command, error) {
+	database := user.Database
+	if database == "" {
+		database = c.DatabaseName
 	}
+	return c.rootCommand(mysqlAddUser(user, database)), nil
+}
+
+func (c *MariaDBConfig) RemoveUserCommand(name, database string) (Command, error) {
+	return c.rootCommand(mysqlDropUser(name)), nil
+}
+
+func (c *MariaDBConfig) ListUsersCommand() Command { return c.rootCommand(mysqlListUsers) }
+
+func (c *MariaDBConfig) CreateDatabaseCommand(name string) (Command, error) {
+	return c.rootCommand("CREATE DATABASE " + mysqlIdentifier(name) + ";"), nil
+}
+
+func (c *MariaDBConfig) DropDatabaseCommand(name string) (Command, error) {
+	return c.rootCommand("DROP DATABASE " + mysqlIdentifier(name) + ";"), nil
+}
+
+func (c *MariaDBConfig) ListDatabasesCommand() Command { return c.rootCommand(mysqlListDatabases) }
+
+func (c *MariaDBConfig) replication() *Replication { return &c.Replication }
+
+// replica returns a copy of the primary that replicates from it. Replicas
+// run the primary's init scripts too, since they skip what the primary ran
+// before they were set up.
+func (c *MariaDBConfig) replica(opts ContainerOptions, index int) replicator {
+	replica := *c
+	opts.InitScripts = c.InitScripts
+	replica.ContainerOptions = opts
+	replica.Replication.Source = memberAddress(c)
+	replica.Replication.ServerID = index + 1
+	return &replica
+}
+
+func (c *MariaDBConfig) primaryCommands() []Command {
+	return []Command{c.rootCommand(mysqlReplicationUser(c.Replication))}
+}
+
+func (c *MariaDBConfig) replicaCommands() []Command {
+	host, port, _ := net.SplitHostPort(c.Replication.Source)
+	return []Command{{
+		Cmd: []string{"sh", "-c", mariadbReplicaScript, "sh", host, port, c.Replication.User},
+		Env: []string{"MYSQL_PWD=" + c.RootPassword, "REPLICATION_PASSWORD=" + c.Replication.Password},
+	}}
+}
+
+func (c *MariaDBConfig) streamingCommand() Command {
+	return mariadbStatus.streamingCommand(c.RootPassword)
+}
+
+// ReplicationStatusCommand reports a replica's threads and lag, or those of
+// every replica of a primary
+func (c *MariaDBConfig) ReplicationStatusCommand() Command {
+	return mariadbStatus.statusCommand(c.RootPassword, c.Role == RoleReplica)
+}
+
+// Load restores the settings from the MariaDB image environment variables
+// and the server id from the command line
+func (c *MariaDBConfig) Load(config *container.Config) {
+	c.Replication.ServerID = mysqlServerID(config.Cmd)
+	env := envMap(config.Env)
+	loadEnv(env, "MARIADB_ROOT_PASSWORD", &c.RootPassword)
+	loadEnv(env, "MARIADB_DATABASE", &c.DatabaseName)
+	loadEnv(env, "MARIADB_USER", &c.User)
+	loadEnv(env, "MARIADB_PASSWORD", &c.Password)
+}
+
+// Settings returns the settings that Configure changes
+func (c *MariaDBConfig) Settings() Settings {
+	return Settings{User: c.User, Password: c.Password, RootPassword: c.RootPassword, Database: c.DatabaseName}
+}
+
+// Configure copies the non-empty settings onto the configuration
+func (c *MariaDBConfig) Configure(s Settings) {
+	override(&c.RootPassword, s.RootPassword)
+	override(&c.DatabaseName, s.Database)
+	override(&c.User, s.User)
+	override(&c.Password, s.Password)
+}
+
+// RequiresPassword reports whether the image refuses to start without a
+// password
+func (c *MariaDBConfig) RequiresPassword() bool { return true }
+
+// SetupMariaDBContainer creates and starts a MariaDB container
+func SetupMariaDBContainer(config MariaDBConfig) error {
+	return Setup(context.Background(), &config)
 }
@@ -2,138 +2,300 @@ package databases
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"time"
 
-	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/client"
-	"github.com/docker/go-connections/nat"
 )
 
+// MongoDBConfig holds configuration for a MongoDB container
 type MongoDBConfig struct {
-	Name     string
-	Image    string
-	Port     string
-	Volume   string
+	ContainerOptions
 	User     string
 	Password string
 	Auth     bool
-    Network  string
+	// ReplicaSet names the replica set the server belongs to; empty for a
+	// standalone server
+	ReplicaSet string
+	// KeyFile authenticates replica set members to each other when
+	// authentication is enabled. It is only known while they are set up.
+	KeyFile string
 }
 
+// mongoPasswordFile holds the root password read through
+// MONGO_INITDB_ROOT_PASSWORD_FILE
+const mongoPasswordFile = "mongo_root_password"
+
+// mongoKeyFile is the name of the replica set keyfile in SecretsPath
+const mongoKeyFile = "mongo_keyfile"
+
+// mongoUID is the uid and gid of the mongodb user in the official image,
+// which must own the keyfile
+const mongoUID = 999
+
+// NewMongoDBConfig returns a default MongoDB configuration
 func NewMongoDBConfig() *MongoDBConfig {
 	return &MongoDBConfig{
-		Image:  "mongo:latest",
-		Name:   "mongodb",
-		Port:   "27017",
-		Volume: "/data/db",
+		ContainerOptions: ContainerOptions{
+			Name:     "mongodb",
+			Image:    "mongo:latest",
+			HostPort: "27017",
+			BindIP:   DefaultBindIP,
+			Volume:   "mongodb_data",
+		},
+		User: "admin",
+	}
+}
+
+func (c *MongoDBConfig) Kind() string             { return "mongodb" }
+func (c *MongoDBConfig) DisplayName() string      { return "MongoDB" }
+func (c *MongoDBConfig) DefaultPort() string      { return "27017" }
+func (c *MongoDBConfig) DataPath() string         { return "/data/db" }
+func (c *MongoDBConfig) InitPath() string         { return "/docker-entrypoint-initdb.d" }
+func (c *MongoDBConfig) InitExtensions() []string { return []string{".sh", ".js"} }
+
+// Cmd joins the server to its replica set and moves it to a custom
+// container port if one was chosen
+func (c *MongoDBConfig) Cmd() []string {
+	var args []string
+	if c.ReplicaSet != "" {
+		args = append(args, "--replSet", c.ReplicaSet)
+		if c.Auth {
+			args = append(args, "--keyFile", secretFile(mongoKeyFile))
+		}
+	}
+	if port := ContainerPort(c); port != c.DefaultPort() {
+		args = append(args, "--port", port)
+	}
+	if args == nil {
+		return nil
+	}
+	return append([]string{"mongod"}, args...)
+}
+
+// Env returns the root credentials when authentication is enabled
+func (c *MongoDBConfig) Env() []string {
+	if !c.Auth {
+		return nil
+	}
+	return []string{
+		"MONGO_INITDB_ROOT_USERNAME=" + c.User,
+		"MONGO_INITDB_ROOT_PASSWORD_FILE=" + secretFile(mongoPasswordFile),
+	}
+}
+
+// SecretFiles returns the root password, and the replica set keyfile while
+// the members are set up, when authentication is enabled
+func (c *MongoDBConfig) SecretFiles() map[string]string {
+	if !c.Auth {
+		return nil
+	}
+	files := map[string]string{mongoPasswordFile: c.Password}
+	if c.KeyFile != "" {
+		files[mongoKeyFile] = c.KeyFile
 	}
+	return files
+}
+
+// SecretOwner gives the keyfile to the mongodb user, since mongod refuses
+// keyfiles other users can read
+func (c *MongoDBConfig) SecretOwner(name string) (int, int, bool) {
+	return mongoUID, mongoUID, name == mongoKeyFile
+}
+
+// ReadyCheck runs the ping command against the container's hostname rather
+// than localhost, since the temporary server used during initialization only
+// listens on localhost. Images before 6.0 ship mongo instead of mongosh.
+func (c *MongoDBConfig) ReadyCheck() ReadyCheck {
+	return ReadyCheck{
+		Command: []string{"sh", "-c", `shell=$(command -v mongosh || command -v mongo) && ` +
+			`"$shell" --quiet --host "$(hostname)" --port ` + ContainerPort(c) + ` --eval 'db.adminCommand({ ping: 1 }).ok'`},
+		Timeout: 60 * time.Second,
+	}
+}
+
+// ConnectionInfo returns the details needed to connect to MongoDB
+func (c *MongoDBConfig) ConnectionInfo() ConnectionInfo {
+	info := ConnectionInfo{
+		Scheme: "mongodb",
+		Host:   c.ConnectHost(),
+		Port:   c.HostPort,
+	}
+	if c.Auth {
+		info.User = c.User
+		info.Password = c.Password
+		info.Query = url.Values{"authSource": {"admin"}}
+	}
+	if c.ReplicaSet != "" {
+		// Drivers would otherwise switch to the addresses of the replica set
+		// configuration, which the host may not resolve
+		if info.Query == nil {
+			info.Query = url.Values{}
+		}
+		info.Query.Set("directConnection", "true")
+	}
+	return info
+}
+
+// mongoPasswordEnv passes the root password to the shells, which would
+// show it in process listings if it were given with --password
+const mongoPasswordEnv = "DOCKERDB_PASSWORD"
+
+// ShellCommand opens mongosh, or the legacy mongo shell on images before 6.0,
+// logged in by a script when authentication is enabled
+func (c *MongoDBConfig) ShellCommand() Command {
+	cmd := []string{"sh", "-c", `exec "$(command -v mongosh || command -v mongo)" "$@"`, "sh",
+		"--port", ContainerPort(c)}
+	if !c.Auth {
+		return Command{Cmd: cmd}
+	}
+	return Command{
+		Cmd: append(cmd, "--eval", c.authScript(), "--shell"),
+		Env: []string{mongoPasswordEnv + "=" + c.Password},
+	}
+}
+
+// authScript logs the shell in as the root user with the password from the
+// environment. The legacy mongo shell cannot read its environment and reads
+// the password file instead.
+func (c *MongoDBConfig) authScript() string {
+	password := "typeof process === 'object' ? process.env." + mongoPasswordEnv +
+		" : cat(" + jsString(secretFile(mongoPasswordFile)) + ")"
+	return "if (!db.getSiblingDB('admin').auth(" + jsString(c.User) + ", " + password + ")) " +
+		"{ throw new Error('authentication failed') }"
+}
+
+// toolCommand runs a MongoDB database tool, logged in through a temporary
+// configuration file when authentication is enabled
+func (c *MongoDBConfig) toolCommand(cmd ...string) Command {
+	if !c.Auth {
+		return Command{Cmd: cmd}
+	}
+	password, _ := json.Marshal(c.Password)
+	cmd = append(cmd, "--username", c.User, "--authenticationDatabase", "admin", "--config", secretFileArg)
+	return secretFileCommand("password: "+string(password), cmd...)
+}
+
+// BackupCommand dumps every database as a mongodump archive
+func (c *MongoDBConfig) BackupCommand() Command {
+	return c.toolCommand("mongodump", "--port", ContainerPort(c), "--archive", "--quiet")
 }
 
+func (c *MongoDBConfig) BackupFormat() string { return "archive" }
+
+// RestoreCommand loads a mongodump archive, replacing existing collections
+func (c *MongoDBConfig) RestoreCommand(format string) (Command, error) {
+	if format != "archive" {
+		return Command{}, unsupportedFormat(c, format)
+	}
+	return c.toolCommand("mongorestore", "--port", ContainerPort(c), "--archive", "--drop", "--quiet"), nil
+}
+
+// MainUser returns the configured user
+func (c *MongoDBConfig) MainUser() string { return c.User }
+
+// SetPassword records a changed password of the configured user
+func (c *MongoDBConfig) SetPassword(user, password string) {
+	if user == c.User {
+		c.Password = password
+	}
+}
+
+// PasswordCommand changes the password of a user defined in the admin
+// database with changeUserPassword
+func (c *MongoDBConfig) PasswordCommand(user, password string) (Command, error) {
+	if !c.Auth {
+		return Command{}, fmt.Errorf("%s runs without authentication, there is no password to change", c.Name)
+	}
+	return c.evalCommand("db.getSiblingDB('admin').changeUserPassword(" + jsString(user) + ", " + jsString(password) + ")"), nil
+}
+
+// evalCommand runs a script in mongosh, or the legacy mongo shell, logged
+// in as the root user when authentication is enabled
+func (c *MongoDBConfig) evalCommand(script string) Command {
+	cmd := []string{"sh", "-c", `exec "$(command -v mongosh || command -v mongo)" "$@"`, "sh",
+		"--port", ContainerPort(c), "--quiet"}
+	if !c.Auth {
+		return Command{Cmd: append(cmd, "--eval", script)}
+	}
+	return Command{
+		Cmd: append(cmd, "--eval", c.authScript()+"\n"+script),
+		Env: []string{mongoPasswordEnv + "=" + c.Password},
+	}
+}
+
+// AddUserCommand creates a user in the database it is granted a built-in
+// role on, which is also the database clients authenticate against
+func (c *MongoDBConfig) AddUserCommand(user User) (Command, error) {
+	if user.Database == "" {
+		return Command{}, fmt.Errorf("MongoDB users belong to a database, choose one with --database")
+	}
+	database := jsString(user.Database)
+	return c.evalCommand("db.getSiblingDB(" + database + ").createUser({user: " + jsString(user.Name) +
+		", pwd: " + jsString(user.Password) + ", roles: [{role: " + jsString(mongoRoles[user.Role]) + ", db: " + database + "}]})"), nil
+}
+
+// RemoveUserCommand drops a user from the database it was created in
+func (c *MongoDBConfig) RemoveUserCommand(name, database string) (Command, error) {
+	if database == "" {
+		return Command{}, fmt.Errorf("MongoDB users belong to a database, choose one with --database")
+	}
+	return c.evalCommand("if (!db.getSiblingDB(" + jsString(database) + ").dropUser(" + jsString(name) + ")) " +
+		"{ throw new Error('no such user') }"), nil
+}
+
+// ListUsersCommand prints every user as user@database
+func (c *MongoDBConfig) ListUsersCommand() Command {
+	return c.evalCommand("db.getSiblingDB('admin').system.users.find().sort({db: 1, user: 1})" +
+		".forEach(function (u) { print(u.user + '@' + u.db) })")
+}
+
+// CreateDatabaseCommand fails, since MongoDB creates databases on first write
+func (c *MongoDBConfig) CreateDatabaseCommand(name string) (Command, error) {
+	return Command{}, fmt.Errorf("MongoDB creates databases on first write, there is nothing to create")
+}
+
+func (c *MongoDBConfig) DropDatabaseCommand(name string) (Command, error) {
+	return c.evalCommand("db.getSiblingDB(" + jsString(name) + ").dropDatabase()"), nil
+}
+
+func (c *MongoDBConfig) ListDatabasesCommand() Command {
+	return c.evalCommand("db.adminCommand({listDatabases: 1}).databases.forEach(function (d) { print(d.name) })")
+}
+
+// Load restores the root credentials from the MongoDB image environment
+// variables and the replica set from the server command line
+func (c *MongoDBConfig) Load(config *container.Config) {
+	for i, arg := range config.Cmd {
+		if arg == "--replSet" && i+1 < len(config.Cmd) {
+			c.ReplicaSet = config.Cmd[i+1]
+		}
+	}
+	env := envMap(config.Env)
+	_, c.Auth = env["MONGO_INITDB_ROOT_USERNAME"]
+	loadEnv(env, "MONGO_INITDB_ROOT_USERNAME", &c.User)
+	loadEnv(env, "MONGO_INITDB_ROOT_PASSWORD", &c.Password)
+}
+
+// Settings returns the settings that Configure changes
+func (c *MongoDBConfig) Settings() Settings {
+	return Settings{User: c.User, Password: c.Password, Auth: c.Auth}
+}
+
+// Configure copies the non-empty settings onto the configuration
+func (c *MongoDBConfig) Configure(s Settings) {
+	c.Auth = c.Auth || s.Auth
+	override(&c.User, s.User)
+	override(&c.Password, s.Password)
+}
+
+// RequiresPassword reports whether the image refuses to start without a
+// password
+func (c *MongoDBConfig) RequiresPassword() bool { return false }
+
+// SetupMongoDB creates and starts a MongoDB container
 func SetupMongoDB(ctx context.Context, config *MongoDBConfig) error {
-    cli, err := client.NewClientWithOpts(client.FromEnv, client.WithVersion("1.40"))
-    if err != nil {
-        return fmt.Errorf("failed to create Docker client: %w", err)
-    }
-    if err := PullImageIfNotExists(ctx, cli, config.Image); err != nil {
-        return fmt.Errorf("failed to ensure MongoDB image: %w", err)
-    }
-    
-    // Create network if specified
-    if config.Network != "" {
-        // Check if network exists
-        networks, err := cli.NetworkList(ctx, types.NetworkListOptions{})
-        if err != nil {
-            return fmt.Errorf("failed to list networks: %w", err)
-        }
-
-        networkExists := false
-        for _, network := range networks {
-            if network.Name == config.Network {
-                networkExists = true
-                break
-            }
-        }
-
-        if !networkExists {
-            fmt.Printf("Creating network: %s...\n", config.Network)
-            _, err = cli.NetworkCreate(ctx, config.Network, types.NetworkCreate{})
-            if err != nil {
-                return fmt.Errorf("failed to create network: %w", err)
-            }
-            fmt.Printf("Successfully created network: %s\n", config.Network)
-        } else {
-            fmt.Printf("Network %s already exists\n", config.Network)
-        }
-    }
-    
-    // Prepare environment variables for auth if enabled
-    var env []string
-    if config.Auth {
-        env = []string{
-            "MONGO_INITDB_ROOT_USERNAME=" + config.User,
-            "MONGO_INITDB_ROOT_PASSWORD=" + config.Password,
-        }
-    }
-    
-    // Create a container
-    containerConfig := &container.Config{
-        Image: config.Image,
-        Env:   env,
-        ExposedPorts: map[nat.Port]struct{}{
-            nat.Port(config.Port): {},
-        },
-    }
-    
-    hostConfig := &container.HostConfig{
-        PortBindings: nat.PortMap{
-            nat.Port(config.Port): []nat.PortBinding{
-                {
-                    HostIP:   "0.0.0.0",
-                    HostPort: config.Port,
-                },
-            },
-        },
-        Binds: []string{config.Volume + ":/data/db"},
-    }
-    
-    // Network config
-    var networkingConfig *network.NetworkingConfig
-    if config.Network != "" {
-        networkingConfig = &network.NetworkingConfig{
-            EndpointsConfig: map[string]*network.EndpointSettings{
-                config.Network: {},
-            },
-        }
-    }
-    
-    resp, err := cli.ContainerCreate(ctx, containerConfig, hostConfig, networkingConfig, nil, config.Name)
-    if err != nil {
-        return fmt.Errorf("failed to create MongoDB container: %w", err)
-    }
-
-    // Start the container
-    if err := cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
-        return fmt.Errorf("failed to start MongoDB container: %w", err)
-    }
-
-    // Wait for the container to be ready
-    timeout := time.After(30 * time.Second)
-    tick := time.Tick(1 * time.Second)
-
-    for {
-        select {
-        case <-timeout:
-            return fmt.Errorf("timeout waiting for MongoDB container to be ready")
-        case <-tick:
-            inspect, err := cli.ContainerInspect(ctx, resp.ID)
-            if err != nil {
-                return fmt.Errorf("failed to inspect MongoDB container: %w", err)
-            }
-            if inspect.State.Running {
-                return nil
-            }
-        }
-    }
-}
\ No newline at end of file
+	return Setup(ctx, config)
+}
@@ -1,56 +1,275 @@
 package databases
 
 import (
-	"fmt"
-	"os/exec"
+	"context"
+	"net"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
 )
 
 // MySQLConfig holds configuration for a MySQL container
 type MySQLConfig struct {
-	Name         string
-	Image        string
-	Port         string
+	ContainerOptions
 	RootPassword string
 	DatabaseName string
 	User         string
 	Password     string
-	Volume       string
-    Network      string
+	Replication  Replication
 }
 
-// SetupMySQLContainer creates and starts a MySQL container
-func SetupMySQLContainer(config MySQLConfig) error {
-	if err := PullImageWithCLI(config.Image); err != nil {
-		return fmt.Errorf("failed to ensure MySQL image: %w", err)
+// NewMySQLConfig returns a default MySQL configuration
+func NewMySQLConfig() *MySQLConfig {
+	return &MySQLConfig{
+		ContainerOptions: ContainerOptions{
+			Name:     "mysql-db",
+			Image:    "mysql:latest",
+			HostPort: "3306",
+			BindIP:   DefaultBindIP,
+			Volume:   "mysql_data",
+		},
+		DatabaseName: "mydb",
 	}
-	if err := CreateNetworkWithCLI(config.Network); err != nil {
-        return fmt.Errorf("failed to create network: %w", err)
-    }
-	args := []string{
-		"run", "-d",
-		"--name", config.Name,
-		"-p", config.Port + ":3306",
-		"-v", config.Volume + ":/var/lib/mysql",
-		"-e", "MYSQL_ROOT_PASSWORD=" + config.RootPassword,
-		"-e", "MYSQL_DATABASE=" + config.DatabaseName,
+}
+
+func (c *MySQLConfig) Kind() string             { return "mysql" }
+func (c *MySQLConfig) DisplayName() string      { return "MySQL" }
+func (c *MySQLConfig) DefaultPort() string      { return "3306" }
+func (c *MySQLConfig) DataPath() string         { return "/var/lib/mysql" }
+func (c *MySQLConfig) InitPath() string         { return "/docker-entrypoint-initdb.d" }
+func (c *MySQLConfig) InitExtensions() []string { return sqlInitExtensions }
+
+// Cmd moves the server to a custom container port if one was chosen and
+// enables GTID based replication on primaries and replicas
+func (c *MySQLConfig) Cmd() []string {
+	var args []string
+	if port := ContainerPort(c); port != c.DefaultPort() {
+		args = append(args, "--port="+port)
+	}
+	if replication := mysqlReplicationArgs(c.Replication, &c.ContainerOptions, ContainerPort(c)); replication != nil {
+		args = append(args, replication...)
+		args = append(args, "--gtid-mode=ON", "--enforce-gtid-consistency=ON")
 	}
+	return args
+}
+
+// mysqlReplicaScript makes a new replica skip the transactions its primary
+// ran before the replica existed, which the replica's own initialization
+// from the same settings already applied, and starts replicating with GTID
+// auto positioning. It is called with the primary's host, port and
+// replication user; MYSQL_PWD holds the root password and
+// REPLICATION_PASSWORD the replication user's. MySQL 8.4 replaced RESET
+// MASTER.
+const mysqlReplicaScript = `set -e
+gtids=$(MYSQL_PWD="$REPLICATION_PASSWORD" mysql --host="$1" --port="$2" --user="$3" --batch --skip-column-names \
+	--execute="SELECT REPLACE(@@GLOBAL.gtid_executed, '\n', '')")
+mysql --user=root --execute='RESET BINARY LOGS AND GTIDS' 2>/dev/null || mysql --user=root --execute='RESET MASTER'
+mysql --user=root --execute="SET GLOBAL gtid_purged = '$gtids';
+CHANGE REPLICATION SOURCE TO SOURCE_HOST = '$1', SOURCE_PORT = $2, SOURCE_USER = '$3',
+	SOURCE_PASSWORD = '$REPLICATION_PASSWORD', SOURCE_AUTO_POSITION = 1, GET_SOURCE_PUBLIC_KEY = 1;
+START REPLICA;"`
 
-	if config.User != "" && config.Password != "" {
-		args = append(args, "-e", "MYSQL_USER="+config.User)
-		args = append(args, "-e", "MYSQL_PASSWORD="+config.Password)
+// Env returns the environment variables understood by the MySQL image
+func (c *MySQLConfig) Env() []string {
+	env := []string{
+		"MYSQL_ROOT_PASSWORD_FILE=" + secretFile("mysql_root_password"),
+		"MYSQL_DATABASE=" + c.DatabaseName,
 	}
+	if c.User != "" && c.Password != "" {
+		env = append(env, "MYSQL_USER="+c.User)
+		env = append(env, "MYSQL_PASSWORD_FILE="+secretFile("mysql_password"))
+	}
+	return env
+}
 
-	if config.Network != "" {
-        args = append(args, "--network", config.Network)
-    }
+// SecretFiles returns the passwords referenced by the *_FILE variables
+func (c *MySQLConfig) SecretFiles() map[string]string {
+	files := map[string]string{"mysql_root_password": c.RootPassword}
+	if c.User != "" && c.Password != "" {
+		files["mysql_password"] = c.Password
+	}
+	return files
+}
 
-	args = append(args, config.Image)
+// ReadyCheck pings the server over TCP, which only succeeds once the
+// temporary server used during initialization has been replaced
+func (c *MySQLConfig) ReadyCheck() ReadyCheck {
+	return ReadyCheck{
+		Command: []string{"mysqladmin", "ping", "--host=127.0.0.1", "--protocol=tcp", "--port=" + ContainerPort(c),
+			"--user=root", "--silent"},
+		Env:     []string{"MYSQL_PWD=" + c.RootPassword},
+		Timeout: 90 * time.Second,
+	}
+}
 
-	cmd := exec.Command("docker", args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to create MySQL container: %v, output: %s", err, output)
+// ConnectionInfo returns the details needed to connect to MySQL
+func (c *MySQLConfig) ConnectionInfo() ConnectionInfo {
+	return ConnectionInfo{
+		Scheme:   "mysql",
+		Host:     c.ConnectHost(),
+		Port:     c.HostPort,
+		Database: c.DatabaseName,
+		User:     c.User,
+		Password: c.Password,
 	}
+}
 
-	return nil
+// ShellCommand opens the mysql client as the application user, or as root
+// when no user was configured
+func (c *MySQLConfig) ShellCommand() Command {
+	user, password := c.User, c.Password
+	if user == "" || password == "" {
+		user, password = "root", c.RootPassword
+	}
+	return Command{
+		Cmd: []string{"mysql", "--user=" + user, c.DatabaseName},
+		Env: []string{"MYSQL_PWD=" + password},
+	}
+}
+
+// BackupCommand dumps the configured database with mysqldump
+func (c *MySQLConfig) BackupCommand() Command {
+	return Command{
+		Cmd: []string{"mysqldump", "--user=root", "--single-transaction", "--routines", "--triggers", "--events",
+			"--databases", c.DatabaseName},
+		Env: []string{"MYSQL_PWD=" + c.RootPassword},
+	}
+}
+
+func (c *MySQLConfig) BackupFormat() string { return "sql" }
+
+// RestoreCommand loads an SQL dump with the mysql client
+func (c *MySQLConfig) RestoreCommand(format string) (Command, error) {
+	if format != "sql" {
+		return Command{}, unsupportedFormat(c, format)
+	}
+	return Command{
+		Cmd: []string{"mysql", "--user=root", c.DatabaseName},
+		Env: []string{"MYSQL_PWD=" + c.RootPassword},
+	}, nil
+}
+
+// rootCommand runs SQL statements as root, printing bare result rows
+func (c *MySQLConfig) rootCommand(sql string) Command {
+	return Command{
+		Cmd: []string{"mysql", "--user=root", "--batch", "--skip-column-names", "--execute=" + sql},
+		Env: []string{"MYSQL_PWD=" + c.RootPassword},
+	}
+}
+
+// MainUser returns the application user, or root when there is none
+func (c *MySQLConfig) MainUser() string {
+	if c.User == "" || c.Password == "" {
+		return "root"
+	}
+	return c.User
+}
+
+// SetPassword records a changed password of root or the application user
+func (c *MySQLConfig) SetPassword(user, password string) {
+	if user == "root" {
+		c.RootPassword = password
+	} else if user == c.User {
+		c.Password = password
+	}
+}
+
+// PasswordCommand changes a user's password with ALTER USER as root
+func (c *MySQLConfig) PasswordCommand(user, password string) (Command, error) {
+	return c.rootCommand(mysqlAlterPassword(user, password)), nil
+}
+
+// AddUserCommand creates a user with its role on the configured database,
+// or on the one the user names
+func (c *MySQLConfig) AddUserCommand(user User) (Command, error) {
+	database := user.Database
+	if database == "" {
+		database = c.DatabaseName
+	}
+	return c.rootCommand(mysqlAddUser(user, database)), nil
+}
+
+func (c *MySQLConfig) RemoveUserCommand(name, database string) (Command, error) {
+	return c.rootCommand(mysqlDropUser(name)), nil
+}
+
+func (c *MySQLConfig) ListUsersCommand() Command { return c.rootCommand(mysqlListUsers) }
+
+func (c *MySQLConfig) CreateDatabaseCommand(name string) (Command, error) {
+	return c.rootCommand("CREATE DATABASE " + mysqlIdentifier(name) + ";"), nil
+}
+
+func (c *MySQLConfig) DropDatabaseCommand(name string) (Command, error) {
+	return c.rootCommand("DROP DATABASE " + mysqlIdentifier(name) + ";"), nil
+}
+
+func (c *MySQLConfig) ListDatabasesCommand() Command { return c.rootCommand(mysqlListDatabases) }
+
+func (c *MySQLConfig) replication() *Replication { return &c.Replication }
+
+// replica returns a copy of the primary that replicates from it. Replicas
+// run the primary's init scripts too, since they skip what the primary ran
+// before they were set up.
+func (c *MySQLConfig) replica(opts ContainerOptions, index int) replicator {
+	replica := *c
+	opts.InitScripts = c.InitScripts
+	replica.ContainerOptions = opts
+	replica.Replication.Source = memberAddress(c)
+	replica.Replication.ServerID = index + 1
+	return &replica
+}
+
+func (c *MySQLConfig) primaryCommands() []Command {
+	return []Command{c.rootCommand(mysqlReplicationUser(c.Replication))}
+}
+
+func (c *MySQLConfig) replicaCommands() []Command {
+	host, port, _ := net.SplitHostPort(c.Replication.Source)
+	return []Command{{
+		Cmd: []string{"sh", "-c", mysqlReplicaScript, "sh", host, port, c.Replication.User},
+		Env: []string{"MYSQL_PWD=" + c.RootPassword, "REPLICATION_PASSWORD=" + c.Replication.Password},
+	}}
+}
+
+func (c *MySQLConfig) streamingCommand() Command {
+	return mysqlStatus.streamingCommand(c.RootPassword)
+}
+
+// ReplicationStatusCommand reports a replica's threads and lag, or those of
+// every replica of a primary
+func (c *MySQLConfig) ReplicationStatusCommand() Command {
+	return mysqlStatus.statusCommand(c.RootPassword, c.Role == RoleReplica)
+}
+
+// Load restores the settings from the MySQL image environment variables
+// and the server id from the command line
+func (c *MySQLConfig) Load(config *container.Config) {
+	c.Replication.ServerID = mysqlServerID(config.Cmd)
+	env := envMap(config.Env)
+	loadEnv(env, "MYSQL_ROOT_PASSWORD", &c.RootPassword)
+	loadEnv(env, "MYSQL_DATABASE", &c.DatabaseName)
+	loadEnv(env, "MYSQL_USER", &c.User)
+	loadEnv(env, "MYSQL_PASSWORD", &c.Password)
+}
+
+// Settings returns the settings that Configure changes
+func (c *MySQLConfig) Settings() Settings {
+	return Settings{User: c.User, Password: c.Password, RootPassword: c.RootPassword, Database: c.DatabaseName}
+}
+
+// Configure copies the non-empty settings onto the configuration
+func (c *MySQLConfig) Configure(s Settings) {
+	override(&c.RootPassword, s.RootPassword)
+	override(&c.DatabaseName, s.Database)
+	override(&c.User, s.User)
+	override(&c.Password, s.Password)
+}
+
+// RequiresPassword reports whether the image refuses to start without a
+// password
+func (c *MySQLConfig) RequiresPassword() bool { return true }
+
+// SetupMySQLContainer creates and starts a MySQL container
+func SetupMySQLContainer(config MySQLConfig) error {
+	return Setup(context.Background(), &config)
 }
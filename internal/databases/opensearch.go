@@ -0,0 +1,111 @@
+package databases
+
+import (
+	"github.com/docker/docker/api/types/container"
+)
+
+// OpenSearchConfig holds configuration for a single-node OpenSearch
+// container. With a password, the security plugin serves HTTPS with its
+// self-signed demo certificates and the admin user logs in with it; without
+// one, the plugin is disabled.
+type OpenSearchConfig struct {
+	ContainerOptions
+	Password string
+}
+
+// openSearchUser is the built-in admin user whose password the image sets
+const openSearchUser = "admin"
+
+// openSearchPasswordFile is the secret file exported as
+// OPENSEARCH_INITIAL_ADMIN_PASSWORD
+const openSearchPasswordFile = "opensearch-password"
+
+// NewOpenSearchConfig returns a default OpenSearch configuration
+func NewOpenSearchConfig() *OpenSearchConfig {
+	return &OpenSearchConfig{
+		ContainerOptions: ContainerOptions{
+			Name:     "opensearch",
+			Image:    "opensearchproject/opensearch:latest",
+			HostPort: "9200",
+			BindIP:   DefaultBindIP,
+			Volume:   "opensearch_data",
+		},
+	}
+}
+
+func (c *OpenSearchConfig) Kind() string        { return "opensearch" }
+func (c *OpenSearchConfig) DisplayName() string { return "OpenSearch" }
+func (c *OpenSearchConfig) DefaultPort() string { return "9200" }
+func (c *OpenSearchConfig) DataPath() string    { return "/usr/share/opensearch/data" }
+func (c *OpenSearchConfig) Cmd() []string       { return nil }
+
+// Env configures a single node through settings passed as environment
+// variables. The admin password is read from a secret file by Entrypoint.
+func (c *OpenSearchConfig) Env() []string {
+	env := []string{"discovery.type=single-node"}
+	if c.Password == "" {
+		env = append(env, "DISABLE_SECURITY_PLUGIN=true", "DISABLE_INSTALL_DEMO_CONFIG=true")
+	}
+	if port := ContainerPort(c); port != c.DefaultPort() {
+		env = append(env, "http.port="+port)
+	}
+	return env
+}
+
+// Entrypoint exports the password file as OPENSEARCH_INITIAL_ADMIN_PASSWORD
+// (OpenSearch 2.12+) before running the image's entrypoint, which only
+// reads the password from its environment
+func (c *OpenSearchConfig) Entrypoint() []string {
+	if c.Password == "" {
+		return nil
+	}
+	return secretEnvEntrypoint("OPENSEARCH_INITIAL_ADMIN_PASSWORD", openSearchPasswordFile,
+		"/usr/share/opensearch/opensearch-docker-entrypoint.sh")
+}
+
+// SecretFiles returns the password read by Entrypoint
+func (c *OpenSearchConfig) SecretFiles() map[string]string {
+	if c.Password == "" {
+		return nil
+	}
+	return map[string]string{openSearchPasswordFile: c.Password}
+}
+
+// ReadyCheck waits for the cluster health to reach yellow
+func (c *OpenSearchConfig) ReadyCheck() ReadyCheck {
+	return searchReadyCheck(c.ConnectionInfo(), ContainerPort(c))
+}
+
+// ConnectionInfo returns the REST endpoint of the node, which uses a
+// self-signed certificate when security is enabled
+func (c *OpenSearchConfig) ConnectionInfo() ConnectionInfo {
+	info := ConnectionInfo{
+		Scheme: "http",
+		Host:   c.ConnectHost(),
+		Port:   c.HostPort,
+	}
+	if c.Password != "" {
+		info.Scheme = "https"
+		info.User = openSearchUser
+		info.Password = c.Password
+	}
+	return info
+}
+
+// Load has nothing to restore, since the password is only kept in a secret
+// file and the credential store
+func (c *OpenSearchConfig) Load(config *container.Config) {}
+
+// Settings returns the settings that Configure changes
+func (c *OpenSearchConfig) Settings() Settings {
+	return Settings{User: c.ConnectionInfo().User, Password: c.Password}
+}
+
+// Configure copies the non-empty settings onto the configuration
+func (c *OpenSearchConfig) Configure(s Settings) {
+	override(&c.Password, s.Password)
+}
+
+// RequiresPassword reports whether the image refuses to start without a
+// password
+func (c *OpenSearchConfig) RequiresPassword() bool { return false }
@@ -0,0 +1,160 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/docker/docker/api/types/filters"
+)
+
+// AutoPort asks Provision to pick the first free host port
+const AutoPort = "auto"
+
+// portSearchRange is how many ports above the preferred one are tried
+const portSearchRange = 100
+
+// ResolvePort validates the requested host port of engine. AutoPort is
+// replaced by the first free port from the engine's default port upwards;
+// an explicit port that is already taken is reported with its owner.
+func ResolvePort(ctx context.Context, backend Backend, engine Engine) error {
+	opts := engine.Options()
+	if err := validateBinding(opts); err != nil {
+		return err
+	}
+	used, err := containerPorts(ctx, backend)
+	if err != nil {
+		return err
+	}
+
+	if opts.HostPort == AutoPort {
+		port, err := freePort(engine.DefaultPort(), opts.BindIP, used)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Using free port %s\n", port)
+		opts.HostPort = port
+		return nil
+	}
+
+	port, err := strconv.Atoi(opts.HostPort)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("invalid port %q, expected a number between 1 and 65535 or %q", opts.HostPort, AutoPort)
+	}
+	// "+80" or "080" must match the ports containers publish as 80
+	opts.HostPort = strconv.Itoa(port)
+	if owner, ok := used[opts.HostPort]; ok {
+		return fmt.Errorf("port %s is already used by %s, choose another port or use --port %s", opts.HostPort, owner, AutoPort)
+	}
+	if !hostPortFree(opts.BindIP, opts.HostPort) {
+		return fmt.Errorf("port %s is already used by %s, choose another port or use --port %s", opts.HostPort, portOwner(opts.HostPort), AutoPort)
+	}
+	return nil
+}
+
+// validateBinding checks the container port and bind address before any
+// host port is looked at
+func validateBinding(opts *ContainerOptions) error {
+	if opts.ContainerPort != "" {
+		if port, err := strconv.Atoi(opts.ContainerPort); err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("invalid container port %q", opts.ContainerPort)
+		}
+	}
+	if opts.BindIP != "" && net.ParseIP(opts.BindIP) == nil {
+		return fmt.Errorf("invalid bind address %q, expected an IP address", opts.BindIP)
+	}
+	if opts.IPv6 && len(portBindings(opts)) == 1 && !strings.Contains(opts.BindIP, ":") {
+		return fmt.Errorf("IPv6 publishing needs --bind 127.0.0.1, 0.0.0.0 or an IPv6 address, not %s", opts.BindIP)
+	}
+	return nil
+}
+
+// freePort returns the first port from preferred upwards that is neither
+// bound by a container nor listened on by a host process on bindIP
+func freePort(preferred, bindIP string, used map[string]string) (string, error) {
+	start, err := strconv.Atoi(preferred)
+	if err != nil {
+		return "", fmt.Errorf("invalid port %q", preferred)
+	}
+	for port := start; port < start+portSearchRange && port <= 65535; port++ {
+		p := strconv.Itoa(port)
+		if _, taken := used[p]; !taken && hostPortFree(bindIP, p) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("no free port between %d and %d", start, start+portSearchRange-1)
+}
+
+// containerPorts maps the host ports published by running containers, and
+// reserved by stopped dockerdb containers, to a description of their owner
+func containerPorts(ctx context.Context, backend Backend) (map[string]string, error) {
+	containers, err := backend.ListContainers(ctx, filters.NewArgs())
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]string)
+	for _, c := range containers {
+		owner := "container " + strings.TrimPrefix(firstName(c.Names), "/")
+		if engine := c.Labels[LabelEngine]; engine != "" {
+			owner += " (dockerdb " + engine + ")"
+		}
+
+		if c.State == "running" {
+			for _, p := range c.Ports {
+				if p.PublicPort != 0 {
+					used[strconv.Itoa(int(p.PublicPort))] = owner
+				}
+			}
+			continue
+		}
+
+		// Stopped containers publish nothing, but starting a dockerdb
+		// container later should not fail because its port was reused
+		if c.Labels[LabelManaged] != "true" {
+			continue
+		}
+		info, err := backend.InspectContainer(ctx, c.ID)
+		if err != nil || info.HostConfig == nil {
+			continue
+		}
+		for _, bindings := range info.HostConfig.PortBindings {
+			for _, b := range bindings {
+				if b.HostPort != "" {
+					used[b.HostPort] = owner + ", stopped"
+				}
+			}
+		}
+	}
+	return used, nil
+}
+
+// firstName returns the first of a container's names
+func firstName(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return names[0]
+}
+
+// hostPortFree reports whether a TCP listener can bind port on bindIP. The
+// check is skipped for addresses that only exist on the Docker host, such as
+// when talking to a remote daemon.
+func hostPortFree(bindIP, port string) bool {
+	if bindIP == "0.0.0.0" || bindIP == "::" {
+		bindIP = ""
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(bindIP, port))
+	if errors.Is(err, syscall.EADDRNOTAVAIL) {
+		return true
+	}
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
@@ -0,0 +1,67 @@
+package databases
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// tcpListen is the socket state of a listening socket in /proc/net/tcp
+const tcpListen = "0A"
+
+// portOwner describes the process listening on port, found by matching the
+// socket inode from /proc/net/tcp{,6} against the open files of processes.
+// Processes of other users are only visible when running as root.
+func portOwner(port string) string {
+	inodes := listeningInodes(port)
+	if len(inodes) > 0 {
+		fds, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+		for _, fd := range fds {
+			link, err := os.Readlink(fd)
+			if err != nil || !inodes[link] {
+				continue
+			}
+			pidDir := filepath.Dir(filepath.Dir(fd))
+			comm, err := os.ReadFile(filepath.Join(pidDir, "comm"))
+			if err != nil {
+				break
+			}
+			return fmt.Sprintf("process %s (pid %s)", strings.TrimSpace(string(comm)), filepath.Base(pidDir))
+		}
+	}
+	return "another process"
+}
+
+// listeningInodes returns the socket links ("socket:[inode]") of the TCP
+// sockets listening on port
+func listeningInodes(port string) map[string]bool {
+	number, err := strconv.Atoi(port)
+	if err != nil {
+		return nil
+	}
+	hexPort := fmt.Sprintf("%04X", number)
+
+	inodes := make(map[string]bool)
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		file, err := os.Open(table)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Scan() // header
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 10 || fields[3] != tcpListen {
+				continue
+			}
+			if strings.HasSuffix(fields[1], ":"+hexPort) {
+				inodes["socket:["+fields[9]+"]"] = true
+			}
+		}
+		file.Close()
+	}
+	return inodes
+}
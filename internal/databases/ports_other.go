@@ -0,0 +1,9 @@
+//go:build !linux
+
+package databases
+
+// portOwner describes the process listening on port. Looking the process
+// up is only implemented on Linux.
+func portOwner(port string) string {
+	return "another process"
+}
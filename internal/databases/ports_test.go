@@ -0,0 +1,126 @@
+package databases
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+// listen holds a free port on 127.0.0.1 until the test ends
+func listen(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+}
+
+// unusedPort returns a port that nothing listened on a moment ago
+func unusedPort(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+}
+
+func TestFreePort(t *testing.T) {
+	busy := listen(t)
+	free := unusedPort(t)
+
+	tests := []struct {
+		name      string
+		preferred string
+		used      map[string]string
+		want      string
+		wantAbove bool
+		wantErr   bool
+	}{
+		{name: "preferred is free", preferred: free, want: free},
+		{name: "used by a container", preferred: free, used: map[string]string{free: "container web"}, wantAbove: true},
+		{name: "used by a host process", preferred: busy, wantAbove: true},
+		{name: "invalid", preferred: "db", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := freePort(tt.preferred, "127.0.0.1", tt.used)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("freePort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("freePort() = %s, want %s", got, tt.want)
+			}
+			if tt.wantAbove && mustAtoi(t, got) <= mustAtoi(t, tt.preferred) {
+				t.Errorf("freePort() = %s, want a port above %s", got, tt.preferred)
+			}
+		})
+	}
+}
+
+func TestResolvePort(t *testing.T) {
+	busy := listen(t)
+	published := unusedPort(t)
+	free := unusedPort(t)
+
+	tests := []struct {
+		name    string
+		port    string
+		bindIP  string
+		wantErr string
+	}{
+		{name: "free", port: free},
+		{name: "auto", port: AutoPort},
+		{name: "published by a container", port: published, wantErr: "port " + published + " is already used by container web (dockerdb redis)"},
+		{name: "used by a host process", port: busy, wantErr: "port " + busy + " is already used by"},
+		{name: "not a number", port: "db", wantErr: `invalid port "db"`},
+		{name: "zero", port: "0", wantErr: `invalid port "0"`},
+		{name: "negative", port: "-1", wantErr: `invalid port "-1"`},
+		{name: "too high", port: "70000", wantErr: `invalid port "70000"`},
+		{name: "invalid bind address", port: free, bindIP: "localhost", wantErr: `invalid bind address "localhost"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newFakeBackend()
+			backend.listed = []types.Container{{
+				Names:  []string{"/web"},
+				State:  "running",
+				Labels: map[string]string{LabelEngine: "redis"},
+				Ports:  []types.Port{{PrivatePort: 6379, PublicPort: mustAtoi(t, published)}},
+			}}
+			engine := NewRedisConfig()
+			engine.HostPort = tt.port
+			engine.BindIP = "127.0.0.1"
+			if tt.bindIP != "" {
+				engine.BindIP = tt.bindIP
+			}
+
+			err := ResolvePort(context.Background(), backend, engine)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ResolvePort() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolvePort() error = %v", err)
+			}
+			if engine.HostPort == AutoPort || engine.HostPort == published || engine.HostPort == busy {
+				t.Errorf("HostPort = %s", engine.HostPort)
+			}
+		})
+	}
+}
+
+func mustAtoi(t *testing.T, s string) uint16 {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return uint16(n)
+}
@@ -1,59 +1,329 @@
 package databases
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
 )
 
 // PostgresConfig holds configuration for a PostgreSQL container
 type PostgresConfig struct {
-	Name     string
-	Image    string
-	Port     string
-	User     string
-	Password string
-	Database string
-	Volume   string
-    Network  string
+	ContainerOptions
+	User        string
+	Password    string
+	Database    string
+	Replication Replication
+}
+
+// pgReplicationPasswordFile holds the password a replica uses to copy the
+// primary's data directory
+const pgReplicationPasswordFile = "postgres_replication_password"
+
+// pgReplicaScript bootstraps an empty data directory from the primary with
+// pg_basebackup, which also writes the standby configuration, before
+// handing over to the image's entrypoint. It is called with the primary's
+// host, port, the replication user and the replica's name, followed by the
+// server command.
+const pgReplicaScript = `set -e
+if [ ! -s "$PGDATA/PG_VERSION" ]; then
+	mkdir -p "$PGDATA"
+	chown postgres "$PGDATA"
+	chmod 700 "$PGDATA"
+	PGPASSWORD="$(cat ` + SecretsPath + "/" + pgReplicationPasswordFile + `)" gosu postgres pg_basebackup \
+		--pgdata="$PGDATA" --write-recovery-conf --wal-method=stream --checkpoint=fast \
+		--dbname="host=$1 port=$2 user=$3 application_name=$4"
+fi
+shift 4
+exec docker-entrypoint.sh "$@"`
+
+// NewPostgresConfig returns a default PostgreSQL configuration
+func NewPostgresConfig() *PostgresConfig {
+	return &PostgresConfig{
+		ContainerOptions: ContainerOptions{
+			Name:     "postgres-db",
+			Image:    "postgres:latest",
+			HostPort: "5432",
+			BindIP:   DefaultBindIP,
+			Volume:   "postgres_data",
+		},
+		User:     "postgres",
+		Database: "postgres",
+	}
+}
+
+func (c *PostgresConfig) Kind() string             { return "postgres" }
+func (c *PostgresConfig) DisplayName() string      { return "PostgreSQL" }
+func (c *PostgresConfig) DefaultPort() string      { return "5432" }
+func (c *PostgresConfig) InitPath() string         { return "/docker-entrypoint-initdb.d" }
+func (c *PostgresConfig) InitExtensions() []string { return sqlInitExtensions }
+
+// DataPath returns where the image keeps its data. From PostgreSQL 18 the
+// image stores it in a versioned directory below /var/lib/postgresql and
+// refuses to start with a volume mounted at the old path. Tags without a
+// version, such as latest or alpine, follow the newest release.
+func (c *PostgresConfig) DataPath() string {
+	if major, ok := postgresMajor(imageTag(c.Image)); ok && major < 18 {
+		return "/var/lib/postgresql/data"
+	}
+	return "/var/lib/postgresql"
+}
+
+// postgresMajor returns the major version a postgres image tag starts
+// with, e.g. 17 for 17.6-alpine
+func postgresMajor(tag string) (int, bool) {
+	end := 0
+	for end < len(tag) && tag[end] >= '0' && tag[end] <= '9' {
+		end++
+	}
+	major, err := strconv.Atoi(tag[:end])
+	return major, err == nil
+}
+
+// Cmd moves the server to a custom container port if one was chosen and
+// bootstraps replicas from their primary
+func (c *PostgresConfig) Cmd() []string {
+	server := []string{"postgres"}
+	if port := ContainerPort(c); port != c.DefaultPort() {
+		server = append(server, "-p", port)
+	}
+	if c.Replication.Source != "" {
+		host, port, _ := net.SplitHostPort(c.Replication.Source)
+		return append([]string{"sh", "-c", pgReplicaScript, "sh", host, port, c.Replication.User, c.Name}, server...)
+	}
+	if len(server) == 1 {
+		return nil
+	}
+	return server
+}
+
+// Env returns the environment variables understood by the PostgreSQL image
+func (c *PostgresConfig) Env() []string {
+	env := []string{"POSTGRES_PASSWORD_FILE=" + secretFile("postgres_password")}
+	if c.User != "" {
+		env = append(env, "POSTGRES_USER="+c.User)
+	}
+	if c.Database != "" {
+		env = append(env, "POSTGRES_DB="+c.Database)
+	}
+	return env
+}
+
+// SecretFiles returns the password referenced by POSTGRES_PASSWORD_FILE,
+// and the replication password while a replica is set up
+func (c *PostgresConfig) SecretFiles() map[string]string {
+	files := map[string]string{"postgres_password": c.Password}
+	if c.Replication.Source != "" && c.Replication.Password != "" {
+		files[pgReplicationPasswordFile] = c.Replication.Password
+	}
+	return files
+}
+
+// ReadyCheck runs pg_isready over TCP, which only succeeds once the
+// temporary server used during initialization has been replaced
+func (c *PostgresConfig) ReadyCheck() ReadyCheck {
+	return ReadyCheck{
+		Command: []string{"pg_isready", "--host=127.0.0.1", "--port=" + ContainerPort(c), "--username=" + c.User, "--dbname=" + c.Database},
+		Timeout: 60 * time.Second,
+	}
+}
+
+// ConnectionInfo returns the details needed to connect to PostgreSQL
+func (c *PostgresConfig) ConnectionInfo() ConnectionInfo {
+	return ConnectionInfo{
+		Scheme:   "postgres",
+		Host:     c.ConnectHost(),
+		Port:     c.HostPort,
+		Database: c.Database,
+		User:     c.User,
+		Password: c.Password,
+	}
+}
+
+// ShellCommand opens psql connected to the configured database
+func (c *PostgresConfig) ShellCommand() Command {
+	return Command{
+		Cmd: []string{"psql", "--port=" + ContainerPort(c), "--username=" + c.User, "--dbname=" + c.Database},
+		Env: []string{"PGPASSWORD=" + c.Password},
+	}
+}
+
+// BackupCommand dumps the configured database as plain SQL with pg_dump.
+// Objects are dropped before being recreated so the dump restores over an
+// existing database.
+func (c *PostgresConfig) BackupCommand() Command {
+	return Command{
+		Cmd: []string{"pg_dump", "--port=" + ContainerPort(c), "--username=" + c.User, "--dbname=" + c.Database, "--clean", "--if-exists"},
+		Env: []string{"PGPASSWORD=" + c.Password},
+	}
+}
+
+func (c *PostgresConfig) BackupFormat() string { return "sql" }
+
+// RestoreCommand loads plain SQL dumps with psql and custom-format dumps
+// with pg_restore
+func (c *PostgresConfig) RestoreCommand(format string) (Command, error) {
+	env := []string{"PGPASSWORD=" + c.Password}
+	switch format {
+	case "sql":
+		return Command{
+			Cmd: []string{"psql", "--port=" + ContainerPort(c), "--username=" + c.User, "--dbname=" + c.Database, "--set=ON_ERROR_STOP=1", "--quiet"},
+			Env: env,
+		}, nil
+	case "pgdump":
+		return Command{
+			Cmd: []string{"pg_restore", "--port=" + ContainerPort(c), "--username=" + c.User, "--dbname=" + c.Database, "--clean", "--if-exists"},
+			Env: env,
+		}, nil
+	}
+	return Command{}, unsupportedFormat(c, format)
+}
+
+// superuserCommand runs SQL as the superuser created by the image while
+// connected to database, printing bare result rows
+func (c *PostgresConfig) superuserCommand(database, sql string) Command {
+	return Command{
+		Cmd: []string{"psql", "--port=" + ContainerPort(c), "--username=" + c.User, "--dbname=" + database,
+			"--set=ON_ERROR_STOP=1", "--quiet", "--tuples-only", "--no-align", "--command=" + sql},
+		Env: []string{"PGPASSWORD=" + c.Password},
+	}
+}
+
+// MainUser returns the configured user
+func (c *PostgresConfig) MainUser() string { return c.User }
+
+// SetPassword records a changed password of the configured user
+func (c *PostgresConfig) SetPassword(user, password string) {
+	if user == c.User {
+		c.Password = password
+	}
 }
 
+// PasswordCommand changes a role's password with ALTER USER
+func (c *PostgresConfig) PasswordCommand(user, password string) (Command, error) {
+	return c.superuserCommand(c.Database, "ALTER USER "+pgIdentifier(user)+" WITH PASSWORD "+pgString(password)), nil
+}
+
+// AddUserCommand creates a login role with its role preset on the
+// configured database, or on the one the user names
+func (c *PostgresConfig) AddUserCommand(user User) (Command, error) {
+	database := user.Database
+	if database == "" {
+		database = c.Database
+	}
+	return c.superuserCommand(database, pgAddUser(user, database)), nil
+}
+
+// RemoveUserCommand drops the objects and privileges a role holds in the
+// given database before dropping the role itself
+func (c *PostgresConfig) RemoveUserCommand(name, database string) (Command, error) {
+	if database == "" {
+		database = c.Database
+	}
+	role := pgIdentifier(name)
+	return c.superuserCommand(database, "DROP OWNED BY "+role+"; DROP ROLE "+role+";"), nil
+}
+
+func (c *PostgresConfig) ListUsersCommand() Command {
+	return c.superuserCommand(c.Database, "SELECT rolname FROM pg_roles WHERE rolname !~ '^pg_' ORDER BY 1;")
+}
+
+func (c *PostgresConfig) CreateDatabaseCommand(name string) (Command, error) {
+	return c.superuserCommand(c.Database, "CREATE DATABASE "+pgIdentifier(name)+";"), nil
+}
+
+func (c *PostgresConfig) DropDatabaseCommand(name string) (Command, error) {
+	if name == c.Database {
+		return Command{}, fmt.Errorf("cannot drop %s, the database dockerdb connects to", name)
+	}
+	return c.superuserCommand(c.Database, "DROP DATABASE "+pgIdentifier(name)+";"), nil
+}
+
+func (c *PostgresConfig) ListDatabasesCommand() Command {
+	return c.superuserCommand(c.Database, "SELECT datname FROM pg_database WHERE NOT datistemplate ORDER BY 1;")
+}
+
+func (c *PostgresConfig) replication() *Replication { return &c.Replication }
+
+// replica returns a copy of the primary that streams from it
+func (c *PostgresConfig) replica(opts ContainerOptions, index int) replicator {
+	replica := *c
+	replica.ContainerOptions = opts
+	replica.Replication.Source = memberAddress(c)
+	return &replica
+}
+
+// primaryCommands creates the replication user and lets it connect for
+// replication from any address. The md5 method also accepts SCRAM
+// passwords, which keeps it working on versions before 14.
+func (c *PostgresConfig) primaryCommands() []Command {
+	return []Command{
+		c.superuserCommand(c.Database, "CREATE ROLE "+pgIdentifier(c.Replication.User)+
+			" WITH REPLICATION LOGIN PASSWORD "+pgString(c.Replication.Password)+";"),
+		{Cmd: []string{"sh", "-c", `echo "host replication $1 all md5" >> "$PGDATA/pg_hba.conf"`, "sh", c.Replication.User}},
+		c.superuserCommand(c.Database, "SELECT pg_reload_conf();"),
+	}
+}
+
+// replicaCommands returns nothing, since replicas start streaming on their own
+func (c *PostgresConfig) replicaCommands() []Command {
+	return nil
+}
+
+func (c *PostgresConfig) streamingCommand() Command {
+	return c.superuserCommand(c.Database, "DO $$ BEGIN IF NOT EXISTS "+
+		"(SELECT 1 FROM pg_stat_wal_receiver WHERE status = 'streaming') "+
+		"THEN RAISE EXCEPTION 'not streaming yet'; END IF; END $$;")
+}
+
+// ReplicationStatusCommand reports every replica with the WAL it still has
+// to replay on a primary, and the age of the last replayed transaction on a
+// replica, which also grows while the primary is idle
+func (c *PostgresConfig) ReplicationStatusCommand() Command {
+	if c.Replication.Source != "" {
+		return c.superuserCommand(c.Database, "SELECT format('streaming from %s:%s (%s), last transaction replayed %s ago', "+
+			"sender_host, sender_port, status, coalesce(date_trunc('second', now() - pg_last_xact_replay_timestamp())::text, 'never')) "+
+			"FROM pg_stat_wal_receiver;")
+	}
+	return c.superuserCommand(c.Database, "SELECT format('%s: %s, %s bytes behind, replay lag %s', "+
+		"application_name, state, pg_wal_lsn_diff(pg_current_wal_lsn(), replay_lsn), coalesce(replay_lag::text, '0')) "+
+		"FROM pg_stat_replication ORDER BY application_name;")
+}
+
+// Load restores the settings from the PostgreSQL image environment variables
+// and the primary of a replica from its command line
+func (c *PostgresConfig) Load(config *container.Config) {
+	if len(config.Cmd) > 6 && config.Cmd[0] == "sh" && config.Cmd[2] == pgReplicaScript {
+		c.Replication.Source = net.JoinHostPort(config.Cmd[4], config.Cmd[5])
+		c.Replication.User = config.Cmd[6]
+	}
+	env := envMap(config.Env)
+	loadEnv(env, "POSTGRES_PASSWORD", &c.Password)
+	loadEnv(env, "POSTGRES_USER", &c.User)
+	c.Database = c.User
+	loadEnv(env, "POSTGRES_DB", &c.Database)
+}
+
+// Settings returns the settings that Configure changes
+func (c *PostgresConfig) Settings() Settings {
+	return Settings{User: c.User, Password: c.Password, Database: c.Database}
+}
+
+// Configure copies the non-empty settings onto the configuration
+func (c *PostgresConfig) Configure(s Settings) {
+	override(&c.Database, s.Database)
+	override(&c.User, s.User)
+	override(&c.Password, s.Password)
+}
+
+// RequiresPassword reports whether the image refuses to start without a
+// password
+func (c *PostgresConfig) RequiresPassword() bool { return true }
+
 // SetupPostgresContainer creates and starts a PostgreSQL container
 func SetupPostgresContainer(config PostgresConfig) error {
-    if err := PullImageWithCLI(config.Image); err != nil {
-        return fmt.Errorf("failed to ensure PostgreSQL image: %w", err)
-    }
-    
-    if err := CreateNetworkWithCLI(config.Network); err != nil {
-        return fmt.Errorf("failed to create network: %w", err)
-    }
-    
-    args := []string{
-        "run", "-d",
-        "--name", config.Name,
-        "-p", config.Port + ":5432",
-        "-v", config.Volume + ":/var/lib/postgresql/data",
-        "-e", "POSTGRES_PASSWORD=" + config.Password,
-    }
-
-    if config.User != "" {
-        args = append(args, "-e", "POSTGRES_USER="+config.User)
-    }
-
-    if config.Database != "" {
-        args = append(args, "-e", "POSTGRES_DB="+config.Database)
-    }
-
-    if config.Network != "" {
-        args = append(args, "--network", config.Network)
-    }
-    
-    args = append(args, config.Image)
-
-    cmd := exec.Command("docker", args...)
-    output, err := cmd.CombinedOutput()
-    if err != nil {
-        return fmt.Errorf("failed to create PostgreSQL container: %v, output: %s", err, output)
-    }
-
-    return nil
+	return Setup(context.Background(), &config)
 }
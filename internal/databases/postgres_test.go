@@ -0,0 +1,29 @@
+package databases
+
+import "testing"
+
+func TestPostgresDataPath(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"postgres:16", "/var/lib/postgresql/data"},
+		{"postgres:17.6-alpine", "/var/lib/postgresql/data"},
+		{"postgres:9.6", "/var/lib/postgresql/data"},
+		{"postgres:18", "/var/lib/postgresql"},
+		{"postgres:18.1-bookworm", "/var/lib/postgresql"},
+		{"postgres:latest", "/var/lib/postgresql"},
+		{"postgres:alpine", "/var/lib/postgresql"},
+		{"postgres", "/var/lib/postgresql"},
+		{"registry.local:5000/postgres:15", "/var/lib/postgresql/data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			c := NewPostgresConfig()
+			c.Image = tt.image
+			if got := c.DataPath(); got != tt.want {
+				t.Errorf("DataPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
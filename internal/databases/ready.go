@@ -0,0 +1,103 @@
+package databases
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"dockerdb/internal/docker"
+)
+
+// probeInterval is the delay between two readiness probes
+const probeInterval = 1 * time.Second
+
+// exitLogLines is the number of log lines included when a container exits
+const exitLogLines = 20
+
+// ExitError reports a container that exited while waiting for it to be ready
+type ExitError struct {
+	Name     string
+	ExitCode int
+	Logs     string
+}
+
+func (e *ExitError) Error() string {
+	msg := fmt.Sprintf("container %s exited with code %d", e.Name, e.ExitCode)
+	if e.Logs != "" {
+		msg += ", last log lines:\n" + strings.TrimRight(e.Logs, "\n")
+	}
+	return msg
+}
+
+// WaitReady runs the engine's readiness probe inside the container until it
+// succeeds, the container exits, or the readiness timeout expires
+func WaitReady(ctx context.Context, backend Backend, engine Engine, containerID string) error {
+	name := engine.DisplayName()
+	check := engine.ReadyCheck()
+	timeout := check.Timeout
+	if engine.Options().ReadyTimeout > 0 {
+		timeout = engine.Options().ReadyTimeout
+	}
+
+	fmt.Printf("Waiting for %s to be ready (timeout %s)", name, timeout)
+	start := time.Now()
+	deadline := time.After(timeout)
+	tick := time.NewTicker(probeInterval)
+	defer tick.Stop()
+
+	var lastOutput string
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return ctx.Err()
+		case <-deadline:
+			fmt.Println()
+			if lastOutput != "" {
+				return fmt.Errorf("timeout after %s waiting for %s to be ready: %s", timeout, name, lastOutput)
+			}
+			return fmt.Errorf("timeout after %s waiting for %s to be ready", timeout, name)
+		case <-tick.C:
+		}
+
+		inspect, err := backend.InspectContainer(ctx, containerID)
+		if err != nil {
+			fmt.Println()
+			return fmt.Errorf("failed to inspect %s container: %w", name, err)
+		}
+		if !inspect.State.Running {
+			fmt.Println()
+			logs, _ := backend.Logs(ctx, containerID, exitLogLines)
+			return &ExitError{
+				Name:     strings.TrimPrefix(inspect.Name, "/"),
+				ExitCode: inspect.State.ExitCode,
+				Logs:     logs,
+			}
+		}
+		fmt.Print(".")
+
+		if len(check.Command) > 0 {
+			var output bytes.Buffer
+			code, err := backend.Exec(ctx, containerID, docker.ExecOptions{
+				Cmd:    check.Command,
+				Env:    check.Env,
+				Stdout: &output,
+				Stderr: &output,
+			})
+			if err != nil {
+				lastOutput = err.Error()
+				continue
+			}
+			if code != 0 {
+				lastOutput = strings.TrimSpace(output.String())
+				continue
+			}
+		}
+
+		fmt.Println()
+		fmt.Printf("%s is ready (took %s)\n", name, time.Since(start).Round(time.Second))
+		return nil
+	}
+}
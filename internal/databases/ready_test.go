@@ -0,0 +1,59 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestWaitReady(t *testing.T) {
+	tests := []struct {
+		name     string
+		exits    bool
+		execCode int
+		wantErr  string
+		wantExit bool
+	}{
+		{name: "ready"},
+		{name: "timeout", execCode: 1, wantErr: "timeout after 1.5s waiting for Redis to be ready: not ready yet"},
+		{name: "container exits", exits: true, wantErr: "container redis exited with code 1, last log lines:\nfatal error", wantExit: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newFakeBackend()
+			backend.exits = tt.exits
+			backend.exitCode = 1
+			backend.execCode = tt.execCode
+			backend.execOutput = "not ready yet\n"
+			backend.logs = "fatal error\n"
+
+			engine := NewRedisConfig()
+			engine.ReadyTimeout = 1500 * time.Millisecond
+			id, err := backend.CreateContainer(context.Background(), engine.Name, nil, &container.HostConfig{}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := backend.StartContainer(context.Background(), id); err != nil {
+				t.Fatal(err)
+			}
+
+			err = WaitReady(context.Background(), backend, engine, id)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("WaitReady() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("WaitReady() error = %v, want %q", err, tt.wantErr)
+			}
+			var exited *ExitError
+			if errors.As(err, &exited) != tt.wantExit {
+				t.Errorf("error is ExitError = %v, want %v", !tt.wantExit, tt.wantExit)
+			}
+		})
+	}
+}
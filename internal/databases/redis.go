@@ -1,140 +1,551 @@
 package databases
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/client"
-	"github.com/docker/go-connections/nat"
 )
 
+// Redis persistence modes
+const (
+	RedisPersistenceRDB  = "rdb"
+	RedisPersistenceAOF  = "aof"
+	RedisPersistenceBoth = "both"
+	RedisPersistenceNone = "none"
+)
+
+// redisEvictionPolicies are the values accepted by maxmemory-policy
+var redisEvictionPolicies = []string{"noeviction", "allkeys-lru", "allkeys-lfu", "allkeys-random",
+	"volatile-lru", "volatile-lfu", "volatile-random", "volatile-ttl"}
+
+// redisFsyncModes are the values accepted by appendfsync
+var redisFsyncModes = []string{"always", "everysec", "no"}
+
 // RedisConfig holds configuration for a Redis container
 type RedisConfig struct {
-	Name     string
-	Image    string
-	Port     string
-	Volume   string
+	ContainerOptions
 	Password string
-	Network  string
+	// MaxMemory limits the dataset size, e.g. "256mb"; empty means no limit
+	MaxMemory string
+	// EvictionPolicy is the maxmemory-policy applied once MaxMemory is reached
+	EvictionPolicy string
+	// Persistence is one of the RedisPersistence modes; empty keeps the
+	// image default, which is RDB snapshots
+	Persistence string
+	// AppendFsync sets how often the append-only file is flushed to disk
+	AppendFsync string
+	// ACLUsers are created from a generated ACL file on first start
+	ACLUsers []User
+	// Replication.Source is the name:port address of the primary a replica
+	// follows
+	Replication Replication
+	// Cluster runs the server as a Redis Cluster node
+	Cluster bool
+
+	// aclFile is set for containers that keep their users in an ACL file
+	aclFile bool
+	// sentinel is set for Sentinels, which watch the primary at monitor
+	sentinel bool
+	monitor  string
 }
 
+// DefaultSentinelMaster is the name Sentinels know the primary by
+const DefaultSentinelMaster = "mymaster"
+
+// redisSentinelPort is the default port of Sentinels
+const redisSentinelPort = "26379"
+
 // NewRedisConfig returns a default Redis configuration
 func NewRedisConfig() *RedisConfig {
 	return &RedisConfig{
-		Name:   "redis",
-		Image:  "redis:latest",
-		Port:   "6379",
-		Volume: "redis_data",
+		ContainerOptions: ContainerOptions{
+			Name:     "redis",
+			Image:    "redis:latest",
+			HostPort: "6379",
+			BindIP:   DefaultBindIP,
+			Volume:   "redis_data",
+		},
 	}
 }
 
-// SetupRedisContainer creates and starts a Redis container
+func (c *RedisConfig) Kind() string        { return "redis" }
+func (c *RedisConfig) DisplayName() string { return "Redis" }
+func (c *RedisConfig) DataPath() string    { return "/data" }
+func (c *RedisConfig) Env() []string       { return nil }
+
+func (c *RedisConfig) DefaultPort() string {
+	if c.sentinel {
+		return redisSentinelPort
+	}
+	return "6379"
+}
+
+// Files generated in SecretsPath. Redis has no environment variable for its
+// password, so it is set by a configuration file, or by the ACL file when
+// there are ACL users. Sentinels rewrite their configuration, so theirs is
+// copied to the data volume on first start.
+const (
+	redisConfigFile   = "redis.conf"
+	redisACLFile      = "users.acl"
+	redisSentinelFile = "sentinel.conf"
+)
+
+// redisSentinelScript starts a Sentinel from its copied configuration
+const redisSentinelScript = `[ -f /data/sentinel.conf ] || cp ` + SecretsPath + "/" + redisSentinelFile + ` /data/sentinel.conf
+exec docker-entrypoint.sh redis-server /data/sentinel.conf --sentinel`
+
+// usesACLFile reports whether the users are kept in an ACL file
+func (c *RedisConfig) usesACLFile() bool {
+	return c.aclFile || len(c.ACLUsers) > 0
+}
+
+// Cmd loads the generated configuration and ACL files and passes every
+// other setting on the command line, where Load can read it back. Members
+// of a topology announce their container name, which clients on the host
+// resolve through /etc/hosts.
+func (c *RedisConfig) Cmd() []string {
+	if c.sentinel {
+		return []string{"sh", "-c", redisSentinelScript}
+	}
+	var args []string
+	if c.configFileContent() != "" {
+		// The configuration file must be the first argument
+		args = append(args, secretFile(redisConfigFile))
+	}
+	if c.usesACLFile() {
+		args = append(args, "--aclfile", secretFile(redisACLFile))
+	}
+	if port := ContainerPort(c); port != c.DefaultPort() {
+		args = append(args, "--port", port)
+	}
+	if c.MaxMemory != "" {
+		args = append(args, "--maxmemory", c.MaxMemory)
+	}
+	if c.EvictionPolicy != "" {
+		args = append(args, "--maxmemory-policy", c.EvictionPolicy)
+	}
+	switch c.Persistence {
+	case RedisPersistenceAOF:
+		args = append(args, "--appendonly", "yes", "--save", "")
+	case RedisPersistenceBoth:
+		args = append(args, "--appendonly", "yes")
+	case RedisPersistenceNone:
+		args = append(args, "--appendonly", "no", "--save", "")
+	}
+	if c.AppendFsync != "" {
+		args = append(args, "--appendfsync", c.AppendFsync)
+	}
+	switch {
+	case c.Cluster:
+		args = append(args, "--cluster-enabled", "yes", "--cluster-announce-hostname", c.Name,
+			"--cluster-preferred-endpoint-type", "hostname")
+	case c.Topology != "":
+		args = append(args, "--replica-announce-ip", c.Name)
+	}
+	if c.Replication.Source != "" {
+		host, port, _ := net.SplitHostPort(c.Replication.Source)
+		args = append(args, "--replicaof", host, port)
+	}
+	if args == nil {
+		return nil
+	}
+	return append([]string{"redis-server"}, args...)
+}
+
+// Validate checks the Redis specific settings
+func (c *RedisConfig) Validate() error {
+	if c.EvictionPolicy != "" && !hasArg(redisEvictionPolicies, c.EvictionPolicy) {
+		return fmt.Errorf("unknown eviction policy %q (expected one of %s)", c.EvictionPolicy, strings.Join(redisEvictionPolicies, ", "))
+	}
+	switch c.Persistence {
+	case "", RedisPersistenceRDB, RedisPersistenceAOF, RedisPersistenceBoth, RedisPersistenceNone:
+	default:
+		return fmt.Errorf("unknown persistence mode %q (expected rdb, aof, both or none)", c.Persistence)
+	}
+	if c.AppendFsync != "" && !hasArg(redisFsyncModes, c.AppendFsync) {
+		return fmt.Errorf("unknown appendfsync mode %q (expected always, everysec or no)", c.AppendFsync)
+	}
+	for _, user := range c.ACLUsers {
+		if user.Name == "" || user.Name == "default" || strings.ContainsAny(user.Name, " \t\n") {
+			return fmt.Errorf("invalid ACL user name %q", user.Name)
+		}
+	}
+	return nil
+}
+
+// SecretFiles returns the configuration file setting the passwords, the ACL
+// file defining every user, or a Sentinel's configuration
+func (c *RedisConfig) SecretFiles() map[string]string {
+	files := make(map[string]string)
+	if c.sentinel {
+		if c.monitor != "" {
+			files[redisSentinelFile] = c.sentinelConfig()
+		}
+		return files
+	}
+	if content := c.configFileContent(); content != "" {
+		files[redisConfigFile] = content
+	}
+	if c.usesACLFile() {
+		files[redisACLFile] = c.aclFileContent()
+	}
+	return files
+}
+
+// configFileContent sets the password, unless the ACL file does, and the
+// password replicas of a topology log in to their primary with, which any
+// member may become after a failover
+func (c *RedisConfig) configFileContent() string {
+	if c.Password == "" {
+		return ""
+	}
+	var content string
+	if !c.usesACLFile() {
+		content += "requirepass " + redisQuote(c.Password) + "\n"
+	}
+	if c.Topology != "" {
+		content += "masterauth " + redisQuote(c.Password) + "\n"
+	}
+	return content
+}
+
+// sentinelConfig makes a Sentinel watch the primary together with two
+// others. Sentinels announce and resolve host names (Redis 6.2+) and share
+// the primary's password.
+func (c *RedisConfig) sentinelConfig() string {
+	host, port, _ := net.SplitHostPort(c.monitor)
+	lines := []string{
+		"port " + ContainerPort(c),
+		"sentinel resolve-hostnames yes",
+		"sentinel announce-hostnames yes",
+		"sentinel announce-ip " + c.Name,
+		"sentinel announce-port " + ContainerPort(c),
+		"sentinel monitor " + DefaultSentinelMaster + " " + host + " " + port + " 2",
+		"sentinel down-after-milliseconds " + DefaultSentinelMaster + " 5000",
+		"sentinel failover-timeout " + DefaultSentinelMaster + " 30000",
+	}
+	if c.Password != "" {
+		password := redisQuote(c.Password)
+		lines = append(lines,
+			"requirepass "+password,
+			"sentinel sentinel-pass "+password,
+			"sentinel auth-pass "+DefaultSentinelMaster+" "+password)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// aclFileContent renders the default user and the ACL users with hashed
+// passwords. Redis 6.2 or later is needed for the pub/sub channel rules.
+func (c *RedisConfig) aclFileContent() string {
+	var content strings.Builder
+	password := "nopass"
+	if c.Password != "" {
+		password = redisPasswordHash(c.Password)
+	}
+	fmt.Fprintf(&content, "user default on %s ~* &* +@all\n", password)
+	for _, user := range c.ACLUsers {
+		fmt.Fprintf(&content, "user %s on %s %s\n", user.Name, redisPasswordHash(user.Password), strings.Join(redisRules[user.Role], " "))
+	}
+	return content.String()
+}
+
+// redisPasswordHash returns the ACL rule setting a password by its SHA-256
+// hash, so the ACL file does not contain it in clear text
+func redisPasswordHash(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return "#" + hex.EncodeToString(sum[:])
+}
+
+// redisQuote quotes s for a Redis configuration file
+func redisQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// ReadyCheck sends PING, which replies PONG once the dataset is loaded
+func (c *RedisConfig) ReadyCheck() ReadyCheck {
+	check := ReadyCheck{
+		Command: []string{"sh", "-c", "redis-cli -h 127.0.0.1 -p " + ContainerPort(c) + " ping | grep -q PONG"},
+		Timeout: 30 * time.Second,
+	}
+	if c.Password != "" {
+		check.Env = []string{"REDISCLI_AUTH=" + c.Password}
+	}
+	return check
+}
+
+// ConnectionInfo returns the details needed to connect to Redis
+func (c *RedisConfig) ConnectionInfo() ConnectionInfo {
+	return ConnectionInfo{
+		Scheme:   "redis",
+		Host:     c.ConnectHost(),
+		Port:     c.HostPort,
+		Password: c.Password,
+	}
+}
+
+// ShellCommand opens redis-cli, authenticating when a password is set
+func (c *RedisConfig) ShellCommand() Command {
+	command := Command{Cmd: []string{"redis-cli", "-p", ContainerPort(c)}}
+	if c.Password != "" {
+		command.Env = []string{"REDISCLI_AUTH=" + c.Password}
+	}
+	return command
+}
+
+// redisBackupScript waits for running background saves to finish, triggers
+// BGSAVE, waits for it to finish and writes the resulting RDB file to
+// stdout. It fails when the save fails or takes longer than
+// $REDIS_BACKUP_TIMEOUT seconds. INFO rather than LASTSAVE tells when the
+// save is done, since LASTSAVE only changes once per second.
+const redisBackupScript = `set -e
+cli() { redis-cli -p "$REDIS_PORT" "$@"; }
+info() { cli INFO persistence | tr -d '\r' | sed -n "s/^$1://p"; }
+deadline=$(( $(date +%s) + REDIS_BACKUP_TIMEOUT ))
+wait_idle() {
+	while [ "$(info rdb_bgsave_in_progress)" != 0 ] || [ "$(info aof_rewrite_in_progress)" != 0 ]; do
+		if [ "$(date +%s)" -ge "$deadline" ]; then
+			echo "timeout after ${REDIS_BACKUP_TIMEOUT}s waiting for the background save" >&2
+			exit 1
+		fi
+		sleep 0.2
+	done
+}
+wait_idle
+reply=$(cli BGSAVE)
+case "$reply" in
+"Background saving started"*) ;;
+*) echo "BGSAVE failed: $reply" >&2; exit 1 ;;
+esac
+wait_idle
+if [ "$(info rdb_last_bgsave_status)" != ok ]; then
+	echo "BGSAVE failed, see the Redis log for details" >&2
+	exit 1
+fi
+dir=$(cli CONFIG GET dir | tail -n 1)
+file=$(cli CONFIG GET dbfilename | tail -n 1)
+cat "$dir/$file"`
+
+// redisBackupTimeout bounds how long a backup waits for BGSAVE
+const redisBackupTimeout = 10 * time.Minute
+
+// BackupCommand snapshots the dataset with BGSAVE and streams the RDB file
+func (c *RedisConfig) BackupCommand() Command {
+	command := Command{
+		Cmd: []string{"sh", "-c", redisBackupScript},
+		Env: []string{
+			"REDIS_PORT=" + ContainerPort(c),
+			"REDIS_BACKUP_TIMEOUT=" + strconv.Itoa(int(redisBackupTimeout.Seconds())),
+		},
+	}
+	if c.Password != "" {
+		command.Env = append(command.Env, "REDISCLI_AUTH="+c.Password)
+	}
+	return command
+}
+
+func (c *RedisConfig) BackupFormat() string { return "rdb" }
+
+// RestorePath returns the RDB file loaded by Redis on startup. Redis loads
+// the append-only file instead when AOF is enabled.
+func (c *RedisConfig) RestorePath(format string) (string, error) {
+	if format != "rdb" {
+		return "", unsupportedFormat(c, format)
+	}
+	if c.Persistence == RedisPersistenceAOF || c.Persistence == RedisPersistenceBoth {
+		return "", fmt.Errorf("%s loads its append-only file on startup, so an RDB dump cannot be restored into it", c.Name)
+	}
+	return c.DataPath() + "/dump.rdb", nil
+}
+
+// MainUser returns the default user, whose password is requirepass
+func (c *RedisConfig) MainUser() string { return "default" }
+
+// SetPassword records a changed password of the default user
+func (c *RedisConfig) SetPassword(user, password string) {
+	if user == "default" {
+		c.Password = password
+	}
+}
+
+// PasswordCommand changes the default user's password with CONFIG SET
+// requirepass, or another ACL user's password with ACL SETUSER on Redis 6+
+func (c *RedisConfig) PasswordCommand(user, password string) (Command, error) {
+	if user == "" || user == "default" {
+		return c.cliCommand("CONFIG", "SET", "requirepass", password), nil
+	}
+	return c.cliCommand("ACL", "SETUSER", user, "resetpass", ">"+password), nil
+}
+
+// redisCLIScript runs redis-cli and fails on error replies, which redis-cli
+// reports with exit code 0
+const redisCLIScript = `out=$(redis-cli "$@") || exit 1
+case "$out" in ERR*|WRONGPASS*|NOPERM*|NOAUTH*) echo "$out" >&2; exit 1 ;; esac
+printf '%s\n' "$out"`
+
+// cliCommand runs redis-cli with the given arguments as the default user
+func (c *RedisConfig) cliCommand(args ...string) Command {
+	command := Command{Cmd: append([]string{"sh", "-c", redisCLIScript, "sh", "-p", ContainerPort(c)}, args...)}
+	if c.Password != "" {
+		command.Env = []string{"REDISCLI_AUTH=" + c.Password}
+	}
+	return command
+}
+
+// AddUserCommand creates an ACL user on Redis 6+ with the rules of its role
+func (c *RedisConfig) AddUserCommand(user User) (Command, error) {
+	args := []string{"ACL", "SETUSER", user.Name, "reset", "on", ">" + user.Password}
+	return c.cliCommand(append(args, redisRules[user.Role]...)...), nil
+}
+
+func (c *RedisConfig) RemoveUserCommand(name, database string) (Command, error) {
+	if name == "default" {
+		return Command{}, fmt.Errorf("the default user cannot be removed")
+	}
+	return c.cliCommand("ACL", "DELUSER", name), nil
+}
+
+func (c *RedisConfig) ListUsersCommand() Command { return c.cliCommand("ACL", "USERS") }
+
+// expectCommand runs redis-cli and succeeds once its output contains want
+func (c *RedisConfig) expectCommand(want string, args ...string) Command {
+	command := Command{Cmd: append([]string{"sh", "-c", `want=$1; shift
+out=$(redis-cli "$@") && case "$out" in *"$want"*) exit 0 ;; esac
+echo "$out" >&2; exit 1`, "sh", want, "-p", ContainerPort(c)}, args...)}
+	if c.Password != "" {
+		command.Env = []string{"REDISCLI_AUTH=" + c.Password}
+	}
+	return command
+}
+
+func (c *RedisConfig) replication() *Replication { return &c.Replication }
+
+// replica returns a copy of the primary that replicates from it
+func (c *RedisConfig) replica(opts ContainerOptions, index int) replicator {
+	replica := *c
+	replica.ContainerOptions = opts
+	replica.Replication.Source = memberAddress(c)
+	return &replica
+}
+
+// primaryCommands returns nothing, since the primary's password is all
+// replicas need
+func (c *RedisConfig) primaryCommands() []Command { return nil }
+
+// replicaCommands returns nothing, since replicas follow the primary given
+// with --replicaof
+func (c *RedisConfig) replicaCommands() []Command { return nil }
+
+func (c *RedisConfig) streamingCommand() Command {
+	return c.expectCommand("master_link_status:up", "INFO", "replication")
+}
+
+// ReplicationStatusCommand prints the replication section of INFO, which
+// lists the replicas with their offsets and lag on a primary. Sentinels
+// print the primary they watch and cluster nodes every node in the cluster.
+func (c *RedisConfig) ReplicationStatusCommand() Command {
+	switch {
+	case c.sentinel:
+		return c.cliCommand("INFO", "sentinel")
+	case c.Cluster:
+		return c.cliCommand("CLUSTER", "NODES")
+	}
+	return c.cliCommand("INFO", "replication")
+}
+
+// saveACL writes the current users of a container using an ACL file back
+// to that file, so users changed at runtime survive restarts. ACL LIST
+// prints the users in ACL file syntax with hashed passwords.
+func (c *RedisConfig) saveACL(ctx context.Context, backend Backend, containerID string) error {
+	users, err := runCommand(ctx, backend, containerID, c.cliCommand("ACL", "LIST"))
+	if err != nil {
+		return fmt.Errorf("failed to read ACL users: %w", err)
+	}
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	if err := tw.WriteHeader(&tar.Header{Name: redisACLFile, Mode: 0o444, Size: int64(len(users))}); err != nil {
+		return err
+	}
+	if _, err := tw.Write([]byte(users)); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := backend.CopyToContainer(ctx, containerID, SecretsPath, &archive); err != nil {
+		return fmt.Errorf("failed to save ACL users: %w", err)
+	}
+	return nil
+}
+
+// Load restores the settings from the server command line. The password
+// is only on the command line of containers created before passwords moved
+// to a file; newer ones rely on the credential store.
+func (c *RedisConfig) Load(config *container.Config) {
+	if len(config.Cmd) == 3 && config.Cmd[2] == redisSentinelScript {
+		c.sentinel = true
+		return
+	}
+	appendOnly, noSave := false, false
+	for i := 0; i+1 < len(config.Cmd); i++ {
+		value := config.Cmd[i+1]
+		switch config.Cmd[i] {
+		case "--requirepass":
+			c.Password = value
+		case "--aclfile":
+			c.aclFile = true
+		case "--maxmemory":
+			c.MaxMemory = value
+		case "--maxmemory-policy":
+			c.EvictionPolicy = value
+		case "--appendonly":
+			appendOnly = value == "yes"
+		case "--save":
+			noSave = value == ""
+		case "--appendfsync":
+			c.AppendFsync = value
+		case "--cluster-enabled":
+			c.Cluster = value == "yes"
+		case "--replicaof":
+			if i+2 < len(config.Cmd) {
+				c.Replication.Source = net.JoinHostPort(value, config.Cmd[i+2])
+			}
+		}
+	}
+	switch {
+	case appendOnly && noSave:
+		c.Persistence = RedisPersistenceAOF
+	case appendOnly:
+		c.Persistence = RedisPersistenceBoth
+	case noSave:
+		c.Persistence = RedisPersistenceNone
+	}
+}
+
+// Settings returns the settings that Configure changes
+func (c *RedisConfig) Settings() Settings {
+	return Settings{Password: c.Password}
+}
+
+// Configure copies the non-empty settings onto the configuration
+func (c *RedisConfig) Configure(s Settings) {
+	override(&c.Password, s.Password)
+}
+
+// RequiresPassword reports whether the image refuses to start without a
+// password
+func (c *RedisConfig) RequiresPassword() bool { return false }
+
 // SetupRedisContainer creates and starts a Redis container
 func SetupRedisContainer(config *RedisConfig) error {
-    ctx := context.Background()
-    cli, err := client.NewClientWithOpts(client.FromEnv, client.WithVersion("1.40"))
-    if err != nil {
-        return fmt.Errorf("failed to create Docker client: %w", err)
-    }
-    if err := PullImageIfNotExists(ctx, cli, config.Image); err != nil {
-        return fmt.Errorf("failed to ensure Redis image: %w", err)
-    }
-
-    // Create network if specified
-    if config.Network != "" {
-        // Check if network exists
-        networks, err := cli.NetworkList(ctx, types.NetworkListOptions{})
-        if err != nil {
-            return fmt.Errorf("failed to list networks: %w", err)
-        }
-
-        networkExists := false
-        for _, network := range networks {
-            if network.Name == config.Network {
-                networkExists = true
-                break
-            }
-        }
-
-        if !networkExists {
-            fmt.Printf("Creating network: %s...\n", config.Network)
-            _, err = cli.NetworkCreate(ctx, config.Network, types.NetworkCreate{})
-            if err != nil {
-                return fmt.Errorf("failed to create network: %w", err)
-            }
-            fmt.Printf("Successfully created network: %s\n", config.Network)
-        } else {
-            fmt.Printf("Network %s already exists\n", config.Network)
-        }
-    }
-
-    // Prepare container configuration
-    containerConfig := &container.Config{
-        Image: config.Image,
-        ExposedPorts: map[nat.Port]struct{}{
-            nat.Port(config.Port): {},
-        },
-    }
-
-    // Add password if provided
-    if config.Password != "" {
-        containerConfig.Cmd = []string{"redis-server", "--requirepass", config.Password}
-    }
-
-    // Host configuration with port mapping and volume
-    hostConfig := &container.HostConfig{
-        PortBindings: nat.PortMap{
-            nat.Port(config.Port): []nat.PortBinding{
-                {
-                    HostIP:   "0.0.0.0",
-                    HostPort: config.Port,
-                },
-            },
-        },
-        Binds: []string{config.Volume + ":/data"},
-    }
-
-    // Network config
-    var networkingConfig *network.NetworkingConfig
-    if config.Network != "" {
-        networkingConfig = &network.NetworkingConfig{
-            EndpointsConfig: map[string]*network.EndpointSettings{
-                config.Network: {},
-            },
-        }
-    }
-
-    // Create container
-    resp, err := cli.ContainerCreate(ctx, containerConfig, hostConfig, networkingConfig, nil, config.Name)
-    if err != nil {
-        return fmt.Errorf("failed to create Redis container: %w", err)
-    }
-
-    // Start the container
-    if err := cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
-        return fmt.Errorf("failed to start Redis container: %w", err)
-    }
-
-    // Wait for the container to be ready
-    timeout := time.After(15 * time.Second)
-    tick := time.Tick(1 * time.Second)
-
-    for {
-        select {
-        case <-timeout:
-            return fmt.Errorf("timeout waiting for Redis container to be ready")
-        case <-tick:
-            inspect, err := cli.ContainerInspect(ctx, resp.ID)
-            if err != nil {
-                return fmt.Errorf("failed to inspect Redis container: %w", err)
-            }
-            if inspect.State.Running {
-                fmt.Println("Redis container is running!")
-                return nil
-            }
-        }
-    }
-}
\ No newline at end of file
+	return Setup(context.Background(), config)
+}
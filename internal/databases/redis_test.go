@@ -0,0 +1,86 @@
+package databases
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestRedisCmdLoadRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(c *RedisConfig)
+		// want adjusts the expected configuration for settings that are
+		// not kept on the command line, or are the image default
+		want func(c *RedisConfig)
+	}{
+		{name: "defaults", config: func(c *RedisConfig) {}},
+		{name: "password only", config: func(c *RedisConfig) { c.Password = "secret" }, want: func(c *RedisConfig) { c.Password = "" }},
+		{name: "memory limit", config: func(c *RedisConfig) {
+			c.MaxMemory = "256mb"
+			c.EvictionPolicy = "allkeys-lru"
+		}},
+		{name: "rdb", config: func(c *RedisConfig) { c.Persistence = RedisPersistenceRDB }, want: func(c *RedisConfig) { c.Persistence = "" }},
+		{name: "aof", config: func(c *RedisConfig) {
+			c.Persistence = RedisPersistenceAOF
+			c.AppendFsync = "always"
+		}},
+		{name: "both", config: func(c *RedisConfig) { c.Persistence = RedisPersistenceBoth }},
+		{name: "none", config: func(c *RedisConfig) { c.Persistence = RedisPersistenceNone }},
+		{name: "acl users", config: func(c *RedisConfig) {
+			c.Password = "secret"
+			c.ACLUsers = []User{{Name: "app", Password: "app-secret", Role: RoleReadWrite}}
+		}, want: func(c *RedisConfig) {
+			c.Password = ""
+			c.ACLUsers = nil
+			c.aclFile = true
+		}},
+		{name: "replica", config: func(c *RedisConfig) {
+			c.Topology = "redis"
+			c.Replication.Source = "redis:6379"
+		}, want: func(c *RedisConfig) { c.Topology = "" }},
+		{name: "cluster node", config: func(c *RedisConfig) {
+			c.Topology = "redis"
+			c.Cluster = true
+		}, want: func(c *RedisConfig) { c.Topology = "" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := NewRedisConfig()
+			tt.config(original)
+
+			cmd := original.Cmd()
+			if original.Password != "" && strings.Contains(strings.Join(cmd, " "), original.Password) {
+				t.Errorf("Cmd() = %q contains the password", cmd)
+			}
+
+			loaded := NewRedisConfig()
+			loaded.Load(&container.Config{Cmd: cmd})
+
+			want := *original
+			if tt.want != nil {
+				tt.want(&want)
+			}
+			if !reflect.DeepEqual(*loaded, want) {
+				t.Errorf("Load(Cmd()) = %+v, want %+v", *loaded, want)
+			}
+		})
+	}
+}
+
+func TestRedisLoadSentinel(t *testing.T) {
+	sentinel := NewRedisConfig()
+	sentinel.sentinel = true
+	sentinel.monitor = "redis:6379"
+
+	loaded := NewRedisConfig()
+	loaded.Load(&container.Config{Cmd: sentinel.Cmd()})
+	if !loaded.sentinel {
+		t.Error("Load() did not recognize a Sentinel")
+	}
+	if got := loaded.DefaultPort(); got != redisSentinelPort {
+		t.Errorf("DefaultPort() = %q, want %q", got, redisSentinelPort)
+	}
+}
@@ -0,0 +1,169 @@
+package databases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// DefaultReplicationUser is the user replicas log in to their primary as
+const DefaultReplicationUser = "replicator"
+
+// maxReplicas bounds the number of read replicas of a primary
+const maxReplicas = 9
+
+// Replication holds the replication settings of a primary or replica
+type Replication struct {
+	// Source is the name:port address of the primary a replica streams
+	// from; empty on a primary
+	Source string
+	User   string
+	// Password is only known while the replicas are set up
+	Password string
+	// ServerID identifies MySQL and MariaDB servers within the topology
+	ServerID int
+}
+
+// replicator is implemented by engines that can run as a primary with read
+// replicas
+type replicator interface {
+	Engine
+	// replication returns the replication settings of the engine.
+	replication() *Replication
+	// replica returns a copy of the engine with the given options that
+	// replicates from it as its index-th replica.
+	replica(opts ContainerOptions, index int) replicator
+	// primaryCommands returns the commands preparing a ready primary for replicas.
+	primaryCommands() []Command
+	// replicaCommands returns the commands starting replication on a ready replica.
+	replicaCommands() []Command
+	// streamingCommand returns a command run on a replica that succeeds
+	// once it receives changes from its primary.
+	streamingCommand() Command
+}
+
+// ReplicationReporter is implemented by engines that report the state of
+// their replication
+type ReplicationReporter interface {
+	// ReplicationStatusCommand returns a command printing a line per replica
+	// when run on a primary, or the replica's own state.
+	ReplicationStatusCommand() Command
+}
+
+// ProvisionReplicas starts engine as a primary followed by the given number
+// of read replicas. The replicas are named after the primary with an index
+// suffix, share a network with it and log in with a generated replication
+// password. When one fails, the containers started so far are removed.
+func ProvisionReplicas(ctx context.Context, backend Backend, engine Engine, replicas int) (*Topology, error) {
+	setup, err := newTopologySetup(ctx, backend, engine)
+	if err != nil {
+		return nil, err
+	}
+	topology, err := provisionReplicas(ctx, backend, setup, engine, replicas)
+	if err != nil {
+		return nil, setup.undo(ctx, err)
+	}
+	return topology, nil
+}
+
+// provisionReplicas sets up the primary and replicas of ProvisionReplicas,
+// recording them in setup
+func provisionReplicas(ctx context.Context, backend Backend, setup *topologySetup, engine Engine, replicas int) (*Topology, error) {
+	primary, ok := engine.(replicator)
+	if !ok {
+		return nil, fmt.Errorf("%s does not support replicas", engine.DisplayName())
+	}
+	if replicas < 1 || replicas > maxReplicas {
+		return nil, fmt.Errorf("between 1 and %d replicas are supported, not %d", maxReplicas, replicas)
+	}
+	password, err := randomSecret()
+	if err != nil {
+		return nil, err
+	}
+	replication := primary.replication()
+	if replication.User == "" {
+		replication.User = DefaultReplicationUser
+	}
+	replication.Password = password
+	replication.ServerID = 1
+
+	opts := engine.Options()
+	opts.Network = topologyNetwork(opts)
+	opts.Topology = opts.Name
+	opts.Role = RolePrimary
+	base := *opts
+
+	announces := announcesAddress(engine)
+	if announces {
+		if err := pinPort(ctx, backend, engine); err != nil {
+			return nil, err
+		}
+	}
+	fmt.Printf("Starting primary %s...\n", opts.Name)
+	if err := setup.provision(ctx, engine); err != nil {
+		return nil, err
+	}
+	for _, command := range primary.primaryCommands() {
+		if _, err := runCommand(ctx, backend, opts.Name, command); err != nil {
+			return nil, fmt.Errorf("failed to prepare %s for replication: %w", opts.Name, err)
+		}
+	}
+
+	topology := &Topology{
+		Name:           fmt.Sprintf("primary with %d replica(s)", replicas),
+		Members:        []Engine{engine},
+		Primary:        opts.Name,
+		ConnectionInfo: engine.ConnectionInfo(),
+	}
+	for i := 1; i <= replicas; i++ {
+		replica := primary.replica(memberOptions(base, i, RoleReplica), i)
+		name := replica.Options().Name
+		if announces {
+			if err := pinPort(ctx, backend, replica); err != nil {
+				return nil, err
+			}
+		}
+		fmt.Printf("Starting replica %s...\n", name)
+		if err := setup.provision(ctx, replica); err != nil {
+			return nil, fmt.Errorf("failed to start replica %s: %w", name, err)
+		}
+		for _, command := range replica.replicaCommands() {
+			if _, err := runCommand(ctx, backend, name, command); err != nil {
+				return nil, fmt.Errorf("failed to start replication on %s: %w", name, err)
+			}
+		}
+		if _, err := waitFor(ctx, backend, name, name+" to replicate from "+opts.Name, topologyWait(engine), replica.streamingCommand()); err != nil {
+			return nil, err
+		}
+		topology.Members = append(topology.Members, replica)
+	}
+	if announces {
+		topology.HostsEntry = hostsEntry(topology.Members)
+	}
+	return topology, nil
+}
+
+// ReplicationStatus returns the replication state of the named container:
+// a line per replica on a primary, or the replica's own state
+func ReplicationStatus(ctx context.Context, backend Backend, name string) ([]string, error) {
+	engine, info, err := runningEngine(ctx, backend, name)
+	if err != nil {
+		return nil, err
+	}
+	reporter, ok := engine.(ReplicationReporter)
+	if !ok {
+		return nil, fmt.Errorf("%s does not report replication status", engine.DisplayName())
+	}
+	output, err := runCommand(ctx, backend, info.ID, reporter.ReplicationStatusCommand())
+	return lines(output), err
+}
+
+// randomSecret returns a random password for accounts only dockerdb uses
+func randomSecret() (string, error) {
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,112 @@
+package databases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DefaultReplicaSet is the name of MongoDB replica sets unless another is chosen
+const DefaultReplicaSet = "rs0"
+
+// maxReplicaSetMembers keeps every member a voting one
+const maxReplicaSetMembers = 7
+
+// mongoKeySize is the number of random bytes in a keyfile, which encode to
+// just under the 1024 characters mongod accepts
+const mongoKeySize = 756
+
+// ProvisionReplicaSet starts a MongoDB replica set with the given number of
+// members. The first member uses config's options and the others are named
+// after it with an index suffix. Every member joins a shared network and
+// listens on its host port inside the container too, so the name:port
+// addresses in the replica set configuration also work from the host once
+// the names resolve. With authentication enabled the members share a
+// generated keyfile. When a member fails, the ones started so far are
+// removed.
+func ProvisionReplicaSet(ctx context.Context, backend Backend, config *MongoDBConfig, size int) (*Topology, error) {
+	if size < 1 || size > maxReplicaSetMembers {
+		return nil, fmt.Errorf("a replica set needs between 1 and %d members, not %d", maxReplicaSetMembers, size)
+	}
+	setup, err := newTopologySetup(ctx, backend, config)
+	if err != nil {
+		return nil, err
+	}
+	topology, err := provisionReplicaSet(ctx, backend, setup, config, size)
+	if err != nil {
+		return nil, setup.undo(ctx, err)
+	}
+	return topology, nil
+}
+
+// provisionReplicaSet sets up the members of ProvisionReplicaSet,
+// recording them in setup
+func provisionReplicaSet(ctx context.Context, backend Backend, setup *topologySetup, config *MongoDBConfig, size int) (*Topology, error) {
+	if config.ReplicaSet == "" {
+		config.ReplicaSet = DefaultReplicaSet
+	}
+	if config.Auth {
+		key := make([]byte, mongoKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate keyfile: %w", err)
+		}
+		config.KeyFile = base64.StdEncoding.EncodeToString(key)
+	}
+	config.Network = topologyNetwork(&config.ContainerOptions)
+	config.Topology = config.Name
+	config.Role = RoleMember
+
+	base := config.ContainerOptions
+	topology := &Topology{Name: "replica set " + config.ReplicaSet}
+	var hosts []string
+	for i := 0; i < size; i++ {
+		member := config
+		if i > 0 {
+			copied := *config
+			copied.ContainerOptions = memberOptions(base, i, RoleMember)
+			member = &copied
+		}
+		if err := pinPort(ctx, backend, member); err != nil {
+			return nil, err
+		}
+		fmt.Printf("Starting replica set member %s...\n", member.Name)
+		if err := setup.provision(ctx, member); err != nil {
+			return nil, fmt.Errorf("failed to start member %s: %w", member.Name, err)
+		}
+		topology.Members = append(topology.Members, member)
+		hosts = append(hosts, fmt.Sprintf("{_id: %d, host: %s}", i, jsString(memberAddress(member))))
+	}
+
+	initiate := "var result = rs.initiate({_id: " + jsString(config.ReplicaSet) + ", members: [" + strings.Join(hosts, ", ") + "]}); " +
+		"if (!result.ok) { throw new Error(result.errmsg) }"
+	if _, err := runCommand(ctx, backend, config.Name, config.evalCommand(initiate)); err != nil {
+		return nil, fmt.Errorf("failed to initiate replica set %s: %w", config.ReplicaSet, err)
+	}
+
+	primary, err := waitFor(ctx, backend, config.Name, "a PRIMARY to be elected", topologyWait(config), config.evalCommand(
+		"var primary = rs.status().members.filter(function (m) { return m.stateStr === 'PRIMARY' })[0]; "+
+			"if (!primary) { throw new Error('no primary yet') } print(primary.name)"))
+	if err != nil {
+		return nil, err
+	}
+	topology.Primary = strings.TrimSpace(primary)
+	topology.ConnectionInfo = replicaSetInfo(config, topology.Members)
+	topology.HostsEntry = hostsEntry(topology.Members)
+	return topology, nil
+}
+
+// replicaSetInfo returns the connection details of the whole replica set
+func replicaSetInfo(config *MongoDBConfig, members []Engine) ConnectionInfo {
+	info := config.ConnectionInfo()
+	info.Query = url.Values{"replicaSet": {config.ReplicaSet}}
+	if config.Auth {
+		info.Query.Set("authSource", "admin")
+	}
+	for _, member := range members {
+		info.Hosts = append(info.Hosts, memberAddress(member))
+	}
+	return info
+}
@@ -0,0 +1,215 @@
+package databases
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"dockerdb/internal/docker"
+)
+
+// Magic bytes used to detect dump formats when no metadata file exists
+var (
+	gzipMagic    = []byte{0x1f, 0x8b}
+	rdbMagic     = []byte("REDIS")
+	archiveMagic = []byte{0x6d, 0xe2, 0x99, 0x81}
+	pgdumpMagic  = []byte("PGDMP")
+)
+
+// ReadBackupMetadata reads the metadata written next to a dump by Backup.
+// It returns nil without error when the dump has no metadata file.
+func ReadBackupMetadata(dumpPath string) (*BackupMetadata, error) {
+	data, err := os.ReadFile(MetadataPath(dumpPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	meta := &BackupMetadata{}
+	if err := json.Unmarshal(data, meta); err != nil {
+		return nil, fmt.Errorf("invalid backup metadata: %w", err)
+	}
+	return meta, nil
+}
+
+// openDump opens a dump file, transparently decompressing gzip, and
+// detects its format from the metadata file or the file contents
+func openDump(dumpPath string) (io.ReadCloser, string, error) {
+	meta, err := ReadBackupMetadata(dumpPath)
+	if err != nil {
+		return nil, "", err
+	}
+	file, err := os.Open(dumpPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	reader := bufio.NewReader(file)
+	var dump io.Reader = reader
+	if head, _ := reader.Peek(len(gzipMagic)); bytes.Equal(head, gzipMagic) {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			file.Close()
+			return nil, "", fmt.Errorf("failed to decompress dump: %w", err)
+		}
+		reader = bufio.NewReader(gz)
+		dump = reader
+	}
+
+	format := ""
+	if meta != nil {
+		format = meta.Format
+	} else {
+		head, _ := reader.Peek(8)
+		switch {
+		case bytes.HasPrefix(head, rdbMagic):
+			format = "rdb"
+		case bytes.HasPrefix(head, archiveMagic):
+			format = "archive"
+		case bytes.HasPrefix(head, pgdumpMagic):
+			format = "pgdump"
+		default:
+			format = "sql"
+		}
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{dump, file}, format, nil
+}
+
+// CheckRestore reports whether engine can restore the dump at dumpPath,
+// so that a container is not created for a dump it cannot load. Dumps
+// whose metadata names another engine are refused.
+func CheckRestore(engine Engine, dumpPath string) error {
+	meta, err := ReadBackupMetadata(dumpPath)
+	if err != nil {
+		return err
+	}
+	if meta != nil && meta.Engine != "" && meta.Engine != engine.Kind() {
+		return fmt.Errorf("%s is a %s dump and cannot be restored into %s", dumpPath, meta.Engine, engine.DisplayName())
+	}
+
+	dump, format, err := openDump(dumpPath)
+	if err != nil {
+		return err
+	}
+	dump.Close()
+
+	switch e := engine.(type) {
+	case Restorer:
+		_, err = e.RestoreCommand(format)
+	case FileRestorer:
+		_, err = e.RestorePath(format)
+	default:
+		err = fmt.Errorf("%s does not support restoring dumps", engine.DisplayName())
+	}
+	return err
+}
+
+// Restore loads the dump at dumpPath into the named container
+func Restore(ctx context.Context, backend Backend, name, dumpPath string) error {
+	info, err := Inspect(ctx, backend, name)
+	if err != nil {
+		return err
+	}
+	engine, err := FromContainer(info)
+	if err != nil {
+		return err
+	}
+	if err := CheckRestore(engine, dumpPath); err != nil {
+		return err
+	}
+
+	dump, format, err := openDump(dumpPath)
+	if err != nil {
+		return err
+	}
+	defer dump.Close()
+	fmt.Printf("Restoring %s dump into %s...\n", format, name)
+
+	switch e := engine.(type) {
+	case Restorer:
+		command, err := e.RestoreCommand(format)
+		if err != nil {
+			return err
+		}
+		var stderr bytes.Buffer
+		code, err := backend.Exec(ctx, info.ID, docker.ExecOptions{
+			Cmd:    command.Cmd,
+			Env:    command.Env,
+			Stdin:  dump,
+			Stdout: io.Discard,
+			Stderr: &stderr,
+		})
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return fmt.Errorf("restore exited with code %d: %s", code, strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	case FileRestorer:
+		target, err := e.RestorePath(format)
+		if err != nil {
+			return err
+		}
+		return replaceDataFile(ctx, backend, engine, info.ID, target, dump)
+	}
+	return fmt.Errorf("%s does not support restoring dumps", engine.DisplayName())
+}
+
+// replaceDataFile stops the container, swaps in the data file and starts the
+// container again, waiting until the database is ready
+func replaceDataFile(ctx context.Context, backend Backend, engine Engine, containerID, target string, content io.Reader) error {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return fmt.Errorf("failed to read dump: %w", err)
+	}
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	header := &tar.Header{
+		Name:    path.Base(target),
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	if err := backend.StopContainer(ctx, containerID); err != nil {
+		return err
+	}
+	if err := backend.CopyToContainer(ctx, containerID, path.Dir(target), &archive); err != nil {
+		return err
+	}
+	if err := backend.StartContainer(ctx, containerID); err != nil {
+		return err
+	}
+	return WaitReady(ctx, backend, engine, containerID)
+}
+
+// unsupportedFormat reports a dump format an engine cannot restore
+func unsupportedFormat(engine Engine, format string) error {
+	return fmt.Errorf("%s cannot restore %s dumps", engine.DisplayName(), format)
+}
@@ -0,0 +1,112 @@
+package databases
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenDump(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    []byte
+		gzip       bool
+		metadata   string
+		wantFormat string
+		wantErr    bool
+	}{
+		{name: "sql", content: []byte("CREATE TABLE t (id int);\n"), wantFormat: "sql"},
+		{name: "gzipped sql", content: []byte("CREATE TABLE t (id int);\n"), gzip: true, wantFormat: "sql"},
+		{name: "rdb", content: []byte("REDIS0011\xfa\x09redis-ver"), wantFormat: "rdb"},
+		{name: "gzipped rdb", content: []byte("REDIS0011\xfa\x09redis-ver"), gzip: true, wantFormat: "rdb"},
+		{name: "mongodb archive", content: []byte{0x6d, 0xe2, 0x99, 0x81, 0x01}, wantFormat: "archive"},
+		{name: "pg_dump custom format", content: []byte("PGDMP\x01\x0e\x00"), wantFormat: "pgdump"},
+		{name: "gzipped pg_dump", content: []byte("PGDMP\x01\x0e\x00"), gzip: true, wantFormat: "pgdump"},
+		{name: "empty", content: nil, wantFormat: "sql"},
+		{name: "metadata wins", content: []byte("REDIS0011"), metadata: `{"format": "sql"}`, wantFormat: "sql"},
+		{name: "broken gzip", content: []byte{0x1f, 0x8b, 0x00}, wantErr: true},
+		{name: "broken metadata", content: []byte("SELECT 1;"), metadata: "{", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dump")
+			data := tt.content
+			if tt.gzip {
+				var buf bytes.Buffer
+				gz := gzip.NewWriter(&buf)
+				gz.Write(tt.content)
+				gz.Close()
+				data = buf.Bytes()
+			}
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.metadata != "" {
+				if err := os.WriteFile(MetadataPath(path), []byte(tt.metadata), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			dump, format, err := openDump(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openDump() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer dump.Close()
+			if format != tt.wantFormat {
+				t.Errorf("format = %q, want %q", format, tt.wantFormat)
+			}
+			got, err := io.ReadAll(dump)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.content) {
+				t.Errorf("content = %q, want %q", got, tt.content)
+			}
+		})
+	}
+}
+
+func TestCheckRestore(t *testing.T) {
+	tests := []struct {
+		name    string
+		engine  Engine
+		content string
+		meta    string
+		wantErr string
+	}{
+		{name: "postgres sql", engine: NewPostgresConfig(), content: "SELECT 1;"},
+		{name: "postgres rdb", engine: NewPostgresConfig(), content: "REDIS0011", wantErr: "PostgreSQL cannot restore rdb dumps"},
+		{name: "redis rdb", engine: NewRedisConfig(), content: "REDIS0011"},
+		{name: "redis sql", engine: NewRedisConfig(), content: "SELECT 1;", wantErr: "Redis cannot restore sql dumps"},
+		{name: "no restore support", engine: NewCassandraConfig(), content: "SELECT 1;", wantErr: "Cassandra does not support restoring dumps"},
+		{name: "same engine", engine: NewMySQLConfig(), content: "SELECT 1;", meta: `{"engine": "mysql", "format": "sql"}`},
+		{name: "other engine", engine: NewMySQLConfig(), content: "SELECT 1;", meta: `{"engine": "postgres", "format": "sql"}`, wantErr: "is a postgres dump and cannot be restored into MySQL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dump")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.meta != "" {
+				if err := os.WriteFile(MetadataPath(path), []byte(tt.meta), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := CheckRestore(tt.engine, path)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("CheckRestore() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("CheckRestore() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package databases
+
+import (
+	"archive/tar"
+	"bytes"
+	"path"
+	"sort"
+)
+
+// SecretsPath is the directory inside containers holding password files
+const SecretsPath = "/run/dockerdb"
+
+// secretFile returns the path of a password file inside the container
+func secretFile(name string) string {
+	return path.Join(SecretsPath, name)
+}
+
+// secretArchive packs the engine's password files into a tar archive to be
+// extracted into /run before the first start. The files are copied rather
+// than bind-mounted so they survive restarts and work with remote daemons,
+// and are read-only for every user since the images read them after
+// dropping root, unless the engine makes them private to that user. It
+// returns nil when the engine has no secrets.
+func secretArchive(engine Engine) (*bytes.Buffer, error) {
+	filer, ok := engine.(SecretFiler)
+	if !ok {
+		return nil, nil
+	}
+	files := filer.SecretFiles()
+	if len(files) == 0 {
+		return nil, nil
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	dir := path.Base(SecretsPath)
+	if err := tw.WriteHeader(&tar.Header{Name: dir + "/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		data := []byte(files[name])
+		header := &tar.Header{Name: dir + "/" + name, Mode: 0o444, Size: int64(len(data))}
+		if owner, ok := engine.(SecretOwner); ok {
+			if uid, gid, private := owner.SecretOwner(name); private {
+				header.Uid, header.Gid, header.Mode = uid, gid, 0o400
+			}
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return &archive, nil
+}
+
+// secretEnvEntrypoint returns an entrypoint that exports the contents of
+// the secret file name as the variable key and then runs entrypoint, for
+// images that only read a password from their environment. The variable
+// only exists in the container's processes, not in its configuration.
+func secretEnvEntrypoint(key, name string, entrypoint ...string) []string {
+	script := key + `=$(cat "$0") || exit 1; export ` + key + `; exec "$@"`
+	return append([]string{"sh", "-c", script, secretFile(name)}, entrypoint...)
+}
+
+// secretFileArg stands for the path of the temporary file created by
+// secretFileCommand in its command's arguments
+const secretFileArg = "{secret-file}"
+
+// secretFileScript writes $DOCKERDB_SECRET to a temporary config.xml only
+// the current user can read, runs its arguments with secretFileArg replaced
+// by the file's path and removes the file afterwards. Some clients pick the
+// format of their configuration by its extension. The shell ignores Ctrl-C,
+// which interactive clients handle themselves, so that it stays around to
+// clean up.
+const secretFileScript = `umask 077
+dir=$(mktemp -d) || exit 1
+trap 'rm -rf "$dir"' EXIT
+trap : INT
+f=$dir/config.xml
+printf '%s\n' "$DOCKERDB_SECRET" > "$f"
+for arg; do
+	shift
+	[ "$arg" = "` + secretFileArg + `" ] && arg=$f
+	set -- "$@" "$arg"
+done
+"$@"`
+
+// secretFileCommand runs cmd with a client configuration file holding
+// secret in place of secretFileArg, for clients that otherwise only take
+// passwords on the command line, where process listings show them
+func secretFileCommand(secret string, cmd ...string) Command {
+	return Command{
+		Cmd: append([]string{"sh", "-c", secretFileScript, "sh"}, cmd...),
+		Env: []string{"DOCKERDB_SECRET=" + secret},
+	}
+}
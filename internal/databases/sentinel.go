@@ -0,0 +1,94 @@
+package databases
+
+import (
+	"context"
+	"fmt"
+)
+
+// redisSentinels is the number of Sentinels watching a primary, the
+// smallest number that still agrees on a failover when one of them is gone
+const redisSentinels = 3
+
+// ProvisionSentinel starts config as a Redis primary with the given number
+// of replicas, then three Sentinels that promote a replica when the primary
+// fails. The Sentinels are named after the primary with a -sentinel-N
+// suffix. Every container listens on its host port inside the container
+// too and announces its name, so the addresses Sentinels hand out work from
+// the host once the names resolve. When a container fails, the ones
+// started so far are removed.
+func ProvisionSentinel(ctx context.Context, backend Backend, config *RedisConfig, replicas int) (*Topology, error) {
+	if config.Cluster {
+		return nil, fmt.Errorf("Redis Cluster nodes cannot be watched by Sentinels")
+	}
+	setup, err := newTopologySetup(ctx, backend, config)
+	if err != nil {
+		return nil, err
+	}
+	topology, err := provisionSentinel(ctx, backend, setup, config, replicas)
+	if err != nil {
+		return nil, setup.undo(ctx, err)
+	}
+	return topology, nil
+}
+
+// provisionSentinel sets up the containers of ProvisionSentinel, recording
+// them in setup
+func provisionSentinel(ctx context.Context, backend Backend, setup *topologySetup, config *RedisConfig, replicas int) (*Topology, error) {
+	topology, err := provisionReplicas(ctx, backend, setup, config, replicas)
+	if err != nil {
+		return nil, err
+	}
+	topology.Name = fmt.Sprintf("primary with %d replica(s) and %d Sentinels", replicas, redisSentinels)
+
+	info := ConnectionInfo{
+		Scheme:   "redis+sentinel",
+		Host:     config.ConnectHost(),
+		Database: DefaultSentinelMaster,
+		Password: config.Password,
+	}
+	var first *RedisConfig
+	for i := 1; i <= redisSentinels; i++ {
+		sentinel := config.sentinelNode(i)
+		if err := pinPort(ctx, backend, sentinel); err != nil {
+			return nil, err
+		}
+		fmt.Printf("Starting Sentinel %s...\n", sentinel.Name)
+		if err := setup.provision(ctx, sentinel); err != nil {
+			return nil, fmt.Errorf("failed to start Sentinel %s: %w", sentinel.Name, err)
+		}
+		if first == nil {
+			first = sentinel
+			info.Port = sentinel.HostPort
+		}
+		topology.Members = append(topology.Members, sentinel)
+		info.Hosts = append(info.Hosts, memberAddress(sentinel))
+	}
+
+	what := fmt.Sprintf("the Sentinels to agree on %s", config.Name)
+	if _, err := waitFor(ctx, backend, first.Name, what, topologyWait(config),
+		first.expectCommand("OK", "SENTINEL", "CKQUORUM", DefaultSentinelMaster)); err != nil {
+		return nil, err
+	}
+	topology.ConnectionInfo = info
+	topology.HostsEntry = hostsEntry(topology.Members)
+	return topology, nil
+}
+
+// sentinelNode returns the index-th Sentinel watching the primary config
+func (c *RedisConfig) sentinelNode(index int) *RedisConfig {
+	opts := c.ContainerOptions
+	opts.Name = fmt.Sprintf("%s-sentinel-%d", c.Name, index)
+	if opts.Volume != "" {
+		opts.Volume = fmt.Sprintf("%s-sentinel-%d", c.Volume, index)
+	}
+	opts.HostPort = AutoPort
+	opts.ContainerPort = ""
+	opts.InitScripts = nil
+	opts.Role = RoleSentinel
+	return &RedisConfig{
+		ContainerOptions: opts,
+		Password:         c.Password,
+		sentinel:         true,
+		monitor:          memberAddress(c),
+	}
+}
@@ -0,0 +1,53 @@
+package databases
+
+// Settings holds engine-specific values that can be set without knowing the
+// engine, e.g. from a project file. Empty fields keep the engine defaults.
+type Settings struct {
+	User         string
+	Password     string
+	RootPassword string
+	Database     string
+	Auth         bool
+}
+
+// Configurer is implemented by engines with users, passwords or databases
+// that can be set through Settings
+type Configurer interface {
+	// Settings returns the current settings, so that Configure(Settings())
+	// changes nothing.
+	Settings() Settings
+	// Configure copies the non-empty settings onto the configuration.
+	Configure(s Settings)
+	// RequiresPassword reports whether the image refuses to start without a
+	// password.
+	RequiresPassword() bool
+}
+
+// Apply copies the non-empty settings onto the engine's configuration
+func Apply(engine Engine, s Settings) {
+	if c, ok := engine.(Configurer); ok {
+		c.Configure(s)
+	}
+}
+
+// Current returns the settings of engine, so that Apply(engine, Current(engine))
+// changes nothing
+func Current(engine Engine) Settings {
+	if c, ok := engine.(Configurer); ok {
+		return c.Settings()
+	}
+	return Settings{}
+}
+
+// RequiresPassword reports whether engine needs a password to start
+func RequiresPassword(engine Engine) bool {
+	c, ok := engine.(Configurer)
+	return ok && c.RequiresPassword()
+}
+
+// override replaces *dst with value unless value is empty
+func override(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
@@ -0,0 +1,304 @@
+package databases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// Snapshot describes a copy of a container's data volume
+type Snapshot struct {
+	Ref     string `json:"ref"`
+	Volume  string `json:"volume"`
+	Source  string `json:"source"`
+	Tag     string `json:"tag"`
+	Engine  string `json:"engine"`
+	Image   string `json:"image"`
+	Created string `json:"created"`
+}
+
+// snapshotTag matches the characters Docker allows in volume names
+var snapshotTag = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// SnapshotVolume returns the name of the volume holding a snapshot. Source
+// and tag may both contain underscores, so the name ends with a hash of
+// the reference to keep a_b:c and a:b_c apart.
+func SnapshotVolume(source, tag string) string {
+	sum := sha256.Sum256([]byte(source + ":" + tag))
+	return "dockerdb_snapshot_" + source + "_" + tag + "_" + hex.EncodeToString(sum[:4])
+}
+
+// ValidateSnapshotTag checks that tag can be part of a volume name
+func ValidateSnapshotTag(tag string) error {
+	if !snapshotTag.MatchString(tag) {
+		return fmt.Errorf("invalid snapshot tag %q, only [a-zA-Z0-9][a-zA-Z0-9_.-] are allowed", tag)
+	}
+	return nil
+}
+
+// ParseSnapshotRef splits a snapshot reference of the form <container>:<tag>
+func ParseSnapshotRef(ref string) (source, tag string, err error) {
+	source, tag, ok := strings.Cut(ref, ":")
+	if !ok || source == "" || tag == "" {
+		return "", "", fmt.Errorf("invalid snapshot %q, expected <container>:<tag>", ref)
+	}
+	if err := ValidateSnapshotTag(tag); err != nil {
+		return "", "", err
+	}
+	return source, tag, nil
+}
+
+// findSnapshot looks up the volume of a snapshot by its source and tag
+// labels
+func findSnapshot(ctx context.Context, backend Backend, source, tag string) (*types.Volume, error) {
+	volumes, err := backend.ListVolumes(ctx, filters.NewArgs(
+		filters.Arg("label", LabelSnapshotSource+"="+source),
+		filters.Arg("label", LabelSnapshot+"="+tag),
+	))
+	if err != nil {
+		return nil, err
+	}
+	if len(volumes) == 0 {
+		return nil, nil
+	}
+	return volumes[0], nil
+}
+
+// CreateSnapshot copies the data volume of the named container into a
+// snapshot volume. A running container is stopped during the copy so the
+// files are consistent, then started again.
+func CreateSnapshot(ctx context.Context, backend Backend, name, tag string) (Snapshot, error) {
+	if err := ValidateSnapshotTag(tag); err != nil {
+		return Snapshot{}, err
+	}
+	info, err := Inspect(ctx, backend, name)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	engine, err := FromContainer(info)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	source := engine.Options().Volume
+	if source == "" {
+		return Snapshot{}, fmt.Errorf("%s has no data volume to snapshot", name)
+	}
+
+	if existing, err := findSnapshot(ctx, backend, name, tag); err != nil {
+		return Snapshot{}, err
+	} else if existing != nil {
+		return Snapshot{}, fmt.Errorf("snapshot %s:%s already exists", name, tag)
+	}
+	target := SnapshotVolume(name, tag)
+	if exists, err := backend.VolumeExists(ctx, target); err != nil {
+		return Snapshot{}, err
+	} else if exists {
+		return Snapshot{}, fmt.Errorf("volume %s already exists", target)
+	}
+
+	env, err := json.Marshal(info.Config.Env)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	cmd, err := json.Marshal(info.Config.Cmd)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	labels := map[string]string{
+		LabelManaged:         "true",
+		LabelSnapshot:        tag,
+		LabelSnapshotSource:  name,
+		LabelSnapshotImage:   info.Config.Image,
+		LabelSnapshotEnv:     string(env),
+		LabelSnapshotCommand: string(cmd),
+		LabelEngine:          engine.Kind(),
+		LabelContainerPort:   ContainerPort(engine),
+		LabelCreated:         time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := backend.CreateVolume(ctx, target, labels); err != nil {
+		return Snapshot{}, err
+	}
+	// discard removes the half-made snapshot so the tag can be used again
+	discard := func() {
+		backend.RemoveVolume(ctx, target)
+		ForgetCredentials(name + ":" + tag)
+	}
+	// The copied data only opens with the source's passwords
+	if err := saveCredentials(name+":"+tag, engine); err != nil {
+		discard()
+		return Snapshot{}, err
+	}
+
+	running := info.State != nil && info.State.Running
+	if running {
+		fmt.Printf("Stopping %s for the snapshot...\n", name)
+		if err := backend.StopContainer(ctx, info.ID); err != nil {
+			discard()
+			return Snapshot{}, err
+		}
+	}
+
+	copyErr := copyVolume(ctx, backend, info.Config.Image, source, target)
+	if copyErr != nil {
+		discard()
+	}
+
+	if running {
+		if err := backend.StartContainer(ctx, info.ID); err != nil {
+			return Snapshot{}, err
+		}
+		if err := WaitReady(ctx, backend, engine, info.ID); err != nil {
+			return Snapshot{}, err
+		}
+	}
+	if copyErr != nil {
+		return Snapshot{}, copyErr
+	}
+
+	return newSnapshot(types.Volume{Name: target, Labels: labels}), nil
+}
+
+// ListSnapshots returns every snapshot volume, sorted by reference
+func ListSnapshots(ctx context.Context, backend Backend) ([]Snapshot, error) {
+	volumes, err := backend.ListVolumes(ctx, filters.NewArgs(filters.Arg("label", LabelSnapshot)))
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]Snapshot, 0, len(volumes))
+	for _, v := range volumes {
+		snapshots = append(snapshots, newSnapshot(*v))
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Ref < snapshots[j].Ref
+	})
+	return snapshots, nil
+}
+
+// newSnapshot describes a snapshot volume from its labels
+func newSnapshot(v types.Volume) Snapshot {
+	return Snapshot{
+		Ref:     v.Labels[LabelSnapshotSource] + ":" + v.Labels[LabelSnapshot],
+		Volume:  v.Name,
+		Source:  v.Labels[LabelSnapshotSource],
+		Tag:     v.Labels[LabelSnapshot],
+		Engine:  v.Labels[LabelEngine],
+		Image:   v.Labels[LabelSnapshotImage],
+		Created: v.Labels[LabelCreated],
+	}
+}
+
+// Clone creates a new container named name whose data volume is a copy of
+// the snapshot ref. An empty port selects the first free port.
+func Clone(ctx context.Context, backend Backend, ref, name, port string) (Engine, error) {
+	source, tag, err := ParseSnapshotRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := findSnapshot(ctx, backend, source, tag)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return nil, fmt.Errorf("snapshot %s not found", ref)
+	}
+
+	engine, err := NewEngine(snapshot.Labels[LabelEngine])
+	if err != nil {
+		return nil, err
+	}
+	config := &container.Config{}
+	if err := json.Unmarshal([]byte(snapshot.Labels[LabelSnapshotEnv]), &config.Env); err != nil {
+		return nil, fmt.Errorf("snapshot %s has invalid settings: %w", ref, err)
+	}
+	if err := json.Unmarshal([]byte(snapshot.Labels[LabelSnapshotCommand]), &config.Cmd); err != nil {
+		return nil, fmt.Errorf("snapshot %s has invalid settings: %w", ref, err)
+	}
+	engine.Load(config)
+	loadCredentials(ref, engine)
+
+	opts := engine.Options()
+	opts.Name = name
+	opts.Image = snapshot.Labels[LabelSnapshotImage]
+	opts.Volume = name + "_data"
+	opts.Network = ""
+	opts.ContainerPort = snapshot.Labels[LabelContainerPort]
+	opts.HostPort = port
+	if port == "" {
+		opts.HostPort = AutoPort
+	}
+
+	if exists, err := backend.VolumeExists(ctx, opts.Volume); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, fmt.Errorf("volume %s already exists", opts.Volume)
+	}
+	if err := backend.CreateVolume(ctx, opts.Volume, nil); err != nil {
+		return nil, err
+	}
+	if err := copyVolume(ctx, backend, opts.Image, snapshot.Name, opts.Volume); err != nil {
+		backend.RemoveVolume(ctx, opts.Volume)
+		return nil, err
+	}
+
+	if err := Provision(ctx, backend, engine); err != nil {
+		removeClone(ctx, backend, name, opts.Volume)
+		return nil, err
+	}
+	return engine, nil
+}
+
+// removeClone removes what a failed Clone left behind: the container, if
+// it was created with the copied volume, and the copied volume itself
+func removeClone(ctx context.Context, backend Backend, name, volume string) {
+	if info, err := Inspect(ctx, backend, name); err == nil {
+		for _, m := range info.Mounts {
+			if m.Type == "volume" && m.Name == volume {
+				backend.RemoveContainer(ctx, info.ID)
+				break
+			}
+		}
+	}
+	backend.RemoveVolume(ctx, volume)
+}
+
+// copyVolume copies every file from one volume to another, preserving
+// ownership and permissions, using a short-lived container of image
+func copyVolume(ctx context.Context, backend Backend, image, from, to string) error {
+	fmt.Printf("Copying volume %s to %s...\n", from, to)
+	config := &container.Config{
+		Image:      image,
+		User:       "root",
+		Entrypoint: []string{"cp", "-a", "/from/.", "/to/"},
+	}
+	hostConfig := &container.HostConfig{
+		Binds: []string{from + ":/from:ro", to + ":/to"},
+	}
+	id, err := backend.CreateContainer(ctx, "", config, hostConfig, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create copy container: %w", err)
+	}
+	defer backend.RemoveContainer(ctx, id)
+
+	if err := backend.StartContainer(ctx, id); err != nil {
+		return err
+	}
+	code, err := backend.WaitContainer(ctx, id)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		logs, _ := backend.Logs(ctx, id, exitLogLines)
+		return fmt.Errorf("copying volume %s failed with code %d: %s", from, code, strings.TrimSpace(logs))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package databases
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseSnapshotRef(t *testing.T) {
+	tests := []struct {
+		ref        string
+		wantSource string
+		wantTag    string
+		wantErr    bool
+	}{
+		{ref: "postgres-db:seeded", wantSource: "postgres-db", wantTag: "seeded"},
+		{ref: "redis:v1.2", wantSource: "redis", wantTag: "v1.2"},
+		{ref: "postgres-db", wantErr: true},
+		{ref: ":seeded", wantErr: true},
+		{ref: "postgres-db:", wantErr: true},
+		{ref: "postgres-db:v1/2", wantErr: true},
+		{ref: "postgres-db:-v1", wantErr: true},
+		{ref: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			source, tag, err := ParseSnapshotRef(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSnapshotRef(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			}
+			if source != tt.wantSource || tag != tt.wantTag {
+				t.Errorf("ParseSnapshotRef(%q) = %q, %q, want %q, %q", tt.ref, source, tag, tt.wantSource, tt.wantTag)
+			}
+		})
+	}
+}
+
+func TestSnapshotAndClone(t *testing.T) {
+	useTempStore(t)
+	ctx := context.Background()
+	backend := newFakeBackend()
+	source := NewRedisConfig()
+	source.Name = "cache"
+	source.Volume = "cache_data"
+	source.Password = "secret"
+	addContainer(t, backend, source, true)
+
+	snapshot, err := CreateSnapshot(ctx, backend, "cache", "seeded")
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+	if snapshot.Ref != "cache:seeded" || snapshot.Engine != "redis" {
+		t.Errorf("CreateSnapshot() = %+v", snapshot)
+	}
+	if info, _ := backend.InspectContainer(ctx, "cache"); !info.State.Running {
+		t.Error("source container was not started again")
+	}
+	if _, err := CreateSnapshot(ctx, backend, "cache", "seeded"); err == nil {
+		t.Error("CreateSnapshot() overwrote an existing snapshot")
+	}
+
+	engine, err := Clone(ctx, backend, "cache:seeded", "cache-copy", "")
+	if err != nil {
+		t.Fatalf("Clone() error = %v", err)
+	}
+	if opts := engine.Options(); opts.Name != "cache-copy" || opts.Volume != "cache-copy_data" {
+		t.Errorf("Clone() options = %+v", *opts)
+	}
+	info, err := backend.InspectContainer(ctx, "cache-copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dataVolume(info) != "cache-copy_data" {
+		t.Errorf("clone mounts %v", info.Mounts)
+	}
+}
+
+func TestSnapshotNamesDoNotCollide(t *testing.T) {
+	useTempStore(t)
+	ctx := context.Background()
+	backend := newFakeBackend()
+	for _, name := range []string{"a_b", "a"} {
+		source := NewRedisConfig()
+		source.Name = name
+		source.Image = "redis:" + name
+		source.Volume = name + "_data"
+		addContainer(t, backend, source, true)
+	}
+
+	first, err := CreateSnapshot(ctx, backend, "a_b", "c")
+	if err != nil {
+		t.Fatalf("CreateSnapshot(a_b, c) error = %v", err)
+	}
+	second, err := CreateSnapshot(ctx, backend, "a", "b_c")
+	if err != nil {
+		t.Fatalf("CreateSnapshot(a, b_c) error = %v", err)
+	}
+	if first.Volume == second.Volume {
+		t.Fatalf("a_b:c and a:b_c share volume %s", first.Volume)
+	}
+
+	for ref, image := range map[string]string{"a_b:c": "redis:a_b", "a:b_c": "redis:a"} {
+		engine, err := Clone(ctx, backend, ref, "clone-"+image[len("redis:"):], "")
+		if err != nil {
+			t.Fatalf("Clone(%s) error = %v", ref, err)
+		}
+		if got := engine.Options().Image; got != image {
+			t.Errorf("Clone(%s) image = %s, want %s", ref, got, image)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package databases
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// mysqlString quotes s as a MySQL string literal
+func mysqlString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// pgString quotes s as a PostgreSQL string literal
+func pgString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// pgIdentifier quotes s as a PostgreSQL identifier
+func pgIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// jsString quotes s as a JavaScript string literal for the mongo shell
+func jsString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
+
+// mysqlAlterPassword returns the ALTER USER statements changing a user's
+// password. The images create root for both '%' and 'localhost'.
+func mysqlAlterPassword(user, password string) string {
+	hosts := []string{"%"}
+	if user == "root" {
+		hosts = append(hosts, "localhost")
+	}
+	var statements []string
+	for _, host := range hosts {
+		statements = append(statements, fmt.Sprintf("ALTER USER %s@%s IDENTIFIED BY %s;",
+			mysqlString(user), mysqlString(host), mysqlString(password)))
+	}
+	return strings.Join(statements, " ")
+}
+
+// mysqlIdentifier quotes s as a MySQL identifier
+func mysqlIdentifier(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+}
+
+// mysqlPrivileges maps each role preset to its MySQL privileges
+var mysqlPrivileges = map[Role]string{
+	RoleReadOnly: "SELECT, SHOW VIEW",
+	RoleReadWrite: "SELECT, INSERT, UPDATE, DELETE, CREATE, ALTER, DROP, INDEX, REFERENCES, " +
+		"CREATE TEMPORARY TABLES, LOCK TABLES, EXECUTE, CREATE VIEW, SHOW VIEW, CREATE ROUTINE, ALTER ROUTINE, TRIGGER, EVENT",
+	RoleAdmin: "ALL PRIVILEGES",
+}
+
+// mysqlAddUser returns the statements creating a user that may connect from
+// anywhere and granting its role on database, or on every database for "*"
+func mysqlAddUser(user User, database string) string {
+	account := mysqlString(user.Name) + "@'%'"
+	target := mysqlIdentifier(database) + ".*"
+	if database == "*" {
+		target = "*.*"
+	}
+	grant := fmt.Sprintf("GRANT %s ON %s TO %s", mysqlPrivileges[user.Role], target, account)
+	if user.Role == RoleAdmin {
+		grant += " WITH GRANT OPTION"
+	}
+	return fmt.Sprintf("CREATE USER %s IDENTIFIED BY %s; %s;", account, mysqlString(user.Password), grant)
+}
+
+// mysqlDropUser returns the statement dropping a user created by mysqlAddUser
+func mysqlDropUser(name string) string {
+	return "DROP USER " + mysqlString(name) + "@'%';"
+}
+
+// mysqlListUsers lists every account except the internal system ones
+const mysqlListUsers = "SELECT CONCAT(User, '@', Host) FROM mysql.user " +
+	"WHERE User NOT LIKE 'mysql.%' AND User NOT IN ('', 'mariadb.sys') ORDER BY 1;"
+
+// mysqlListDatabases lists every database except the system schemas
+const mysqlListDatabases = "SELECT SCHEMA_NAME FROM information_schema.SCHEMATA " +
+	"WHERE SCHEMA_NAME NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys') ORDER BY 1;"
+
+// pgPrivileges maps each role preset to the statements granting it on the
+// public schema of the current database, including tables created later by
+// the superuser
+var pgPrivileges = map[Role][]string{
+	RoleReadOnly: {
+		"GRANT USAGE ON SCHEMA public TO %[1]s",
+		"GRANT SELECT ON ALL TABLES IN SCHEMA public TO %[1]s",
+		"ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT ON TABLES TO %[1]s",
+	},
+	RoleReadWrite: {
+		"GRANT USAGE, CREATE ON SCHEMA public TO %[1]s",
+		"GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO %[1]s",
+		"GRANT USAGE, SELECT, UPDATE ON ALL SEQUENCES IN SCHEMA public TO %[1]s",
+		"ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO %[1]s",
+		"ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT, UPDATE ON SEQUENCES TO %[1]s",
+	},
+	RoleAdmin: {
+		"GRANT ALL ON SCHEMA public TO %[1]s",
+		"GRANT ALL ON ALL TABLES IN SCHEMA public TO %[1]s",
+		"GRANT ALL ON ALL SEQUENCES IN SCHEMA public TO %[1]s",
+		"ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT ALL ON TABLES TO %[1]s",
+		"ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT ALL ON SEQUENCES TO %[1]s",
+	},
+}
+
+// pgAddUser returns the statements creating a login role and granting its
+// role preset; they must run connected to database
+func pgAddUser(user User, database string) string {
+	role := pgIdentifier(user.Name)
+	statements := []string{
+		"CREATE ROLE " + role + " LOGIN PASSWORD " + pgString(user.Password),
+		"GRANT CONNECT ON DATABASE " + pgIdentifier(database) + " TO " + role,
+	}
+	if user.Role == RoleAdmin {
+		statements = append(statements, "GRANT ALL ON DATABASE "+pgIdentifier(database)+" TO "+role)
+	}
+	for _, grant := range pgPrivileges[user.Role] {
+		statements = append(statements, fmt.Sprintf(grant, role))
+	}
+	return strings.Join(statements, "; ") + ";"
+}
+
+// mongoRoles maps each role preset to a MongoDB built-in role
+var mongoRoles = map[Role]string{
+	RoleReadOnly:  "read",
+	RoleReadWrite: "readWrite",
+	RoleAdmin:     "dbOwner",
+}
+
+// redisRules maps each role preset to Redis ACL rules
+var redisRules = map[Role][]string{
+	RoleReadOnly:  {"~*", "&*", "+@read", "+@connection"},
+	RoleReadWrite: {"~*", "&*", "+@all", "-@dangerous"},
+	RoleAdmin:     {"~*", "&*", "+@all"},
+}
+
+// mysqlReplicationArgs returns the server options of a MySQL or MariaDB
+// primary or replica. Replicas report their address, so that the primary
+// can list them, and only accept writes from replication and admins.
+func mysqlReplicationArgs(r Replication, opts *ContainerOptions, port string) []string {
+	if r.ServerID == 0 {
+		return nil
+	}
+	args := []string{"--server-id=" + strconv.Itoa(r.ServerID), "--log-bin=mysql-bin"}
+	if opts.Role == RoleReplica {
+		args = append(args, "--read-only=ON", "--report-host="+opts.Name, "--report-port="+port)
+	}
+	return args
+}
+
+// mysqlServerID returns the value of --server-id in a server command line
+func mysqlServerID(cmd []string) int {
+	for _, arg := range cmd {
+		if value, ok := strings.CutPrefix(arg, "--server-id="); ok {
+			id, _ := strconv.Atoi(value)
+			return id
+		}
+	}
+	return 0
+}
+
+// mysqlReplicationUser creates the user replicas log in to the primary as
+func mysqlReplicationUser(r Replication) string {
+	account := mysqlString(r.User) + "@'%'"
+	return "CREATE USER " + account + " IDENTIFIED BY " + mysqlString(r.Password) + "; " +
+		"GRANT REPLICATION SLAVE ON *.* TO " + account + ";"
+}
+
+// mysqlReplicaStatus names the statements and status fields of replication
+// in MySQL 8.0.22 and later, and in MariaDB, which kept the older names
+type mysqlReplicaStatus struct {
+	client     string
+	showHosts  string
+	showStatus string
+	source     string
+	ioRunning  string
+	sqlRunning string
+	lag        string
+}
+
+var (
+	mysqlStatus = mysqlReplicaStatus{
+		client:     "mysql",
+		showHosts:  "SHOW REPLICAS",
+		showStatus: "SHOW REPLICA STATUS",
+		source:     "Source_Host",
+		ioRunning:  "Replica_IO_Running",
+		sqlRunning: "Replica_SQL_Running",
+		lag:        "Seconds_Behind_Source",
+	}
+	mariadbStatus = mysqlReplicaStatus{
+		client:     `"$(command -v mariadb || command -v mysql)"`,
+		showHosts:  "SHOW SLAVE HOSTS",
+		showStatus: "SHOW SLAVE STATUS",
+		source:     "Master_Host",
+		ioRunning:  "Slave_IO_Running",
+		sqlRunning: "Slave_SQL_Running",
+		lag:        "Seconds_Behind_Master",
+	}
+)
+
+// replicaScript prints the state of the replica reached with the given
+// client options on one line
+func (s mysqlReplicaStatus) replicaScript(options string) string {
+	fields := strings.Join([]string{s.source, s.ioRunning, s.sqlRunning, s.lag, "Last_IO_Error", "Last_SQL_Error"}, "|")
+	return s.client + ` --user=root ` + options + ` --execute='` + s.showStatus + `\G' | ` +
+		`awk -F': ' '$1 ~ /^ *(` + fields + `)$/ && $2 != "" { sub(/^ +/, "", $1); printf "%s%s: %s", sep, $1, $2; sep = ", " } END { print "" }'`
+}
+
+// statusCommand reports a replica's own state, or on a primary the state
+// of every replica it lists, which all share the primary's root password
+func (s mysqlReplicaStatus) statusCommand(rootPassword string, replica bool) Command {
+	script := s.replicaScript("")
+	if !replica {
+		script = s.client + ` --user=root --batch --skip-column-names --execute='` + s.showHosts + `' |
+while read -r id host port rest; do
+	printf '%s: ' "$host"
+	` + s.replicaScript(`--host="$host" --port="$port"`) + `
+done`
+	}
+	return Command{
+		Cmd: []string{"sh", "-c", script},
+		Env: []string{"MYSQL_PWD=" + rootPassword},
+	}
+}
+
+// streamingCommand succeeds once both replication threads of a replica run,
+// reporting the last errors otherwise
+func (s mysqlReplicaStatus) streamingCommand(rootPassword string) Command {
+	script := `status=$(` + s.client + ` --user=root --execute='` + s.showStatus + `\G')
+echo "$status" | grep -q '` + s.ioRunning + `: Yes' && echo "$status" | grep -q '` + s.sqlRunning + `: Yes' && exit 0
+echo "$status" | grep -E 'Last_(IO_|SQL_)?Error: .' >&2
+exit 1`
+	return Command{
+		Cmd: []string{"sh", "-c", script},
+		Env: []string{"MYSQL_PWD=" + rootPassword},
+	}
+}
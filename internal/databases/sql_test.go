@@ -0,0 +1,34 @@
+package databases
+
+import "testing"
+
+func TestQuoting(t *testing.T) {
+	tests := []struct {
+		name  string
+		quote func(string) string
+		in    string
+		want  string
+	}{
+		{"mysqlString plain", mysqlString, "secret", `'secret'`},
+		{"mysqlString quote", mysqlString, "it's", `'it''s'`},
+		{"mysqlString backslash", mysqlString, `a\'b`, `'a\\''b'`},
+		{"mysqlIdentifier plain", mysqlIdentifier, "app", "`app`"},
+		{"mysqlIdentifier backtick", mysqlIdentifier, "a`b", "`a``b`"},
+		{"pgString quote", pgString, "it's", `'it''s'`},
+		{"pgString backslash", pgString, `a\b`, `'a\b'`},
+		{"pgIdentifier plain", pgIdentifier, "app", `"app"`},
+		{"pgIdentifier quote", pgIdentifier, `a"b`, `"a""b"`},
+		{"jsString quote", jsString, `it's "x"`, `"it's \"x\""`},
+		{"jsString control", jsString, "a\nb\\", `"a\nb\\"`},
+		{"jsString html", jsString, "<a>&", `"\u003ca\u003e\u0026"`},
+		{"redisQuote plain", redisQuote, "secret", `"secret"`},
+		{"redisQuote quote", redisQuote, `a"b\c`, `"a\"b\\c"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.quote(tt.in); got != tt.want {
+				t.Errorf("%s(%q) = %s, want %s", tt.name, tt.in, got, tt.want)
+			}
+		})
+	}
+}
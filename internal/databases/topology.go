@@ -0,0 +1,233 @@
+package databases
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Roles of the containers in a topology
+const (
+	RolePrimary  = "primary"
+	RoleReplica  = "replica"
+	RoleMember   = "member"
+	RoleSentinel = "sentinel"
+)
+
+// Topology describes the containers of a replica set or cluster once it is
+// set up
+type Topology struct {
+	// Name describes the topology, e.g. "replica set rs0"
+	Name    string
+	Members []Engine
+	// Primary is the address of the member accepting writes
+	Primary string
+	// ConnectionInfo connects clients to the topology as a whole
+	ConnectionInfo ConnectionInfo
+	// HostsEntry is the /etc/hosts line clients on the host need when
+	// ConnectionInfo uses the members' container names
+	HostsEntry string
+}
+
+// topologySetup records what setting up a topology created, so that the
+// members started before one fails can be removed again
+type topologySetup struct {
+	backend Backend
+	// network is the topology network when the setup creates it
+	network string
+	members []string
+	volumes []string
+}
+
+// newTopologySetup starts recording the setup of the topology whose first
+// container is engine
+func newTopologySetup(ctx context.Context, backend Backend, engine Engine) (*topologySetup, error) {
+	setup := &topologySetup{backend: backend}
+	network := topologyNetwork(engine.Options())
+	exists, err := backend.NetworkExists(ctx, network)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		setup.network = network
+	}
+	return setup, nil
+}
+
+// provision provisions a member, recording its container and, unless it
+// already existed, its data volume
+func (s *topologySetup) provision(ctx context.Context, engine Engine) error {
+	opts := engine.Options()
+	// Never record, and so remove, a container that was there before
+	if _, err := s.backend.InspectContainer(ctx, opts.Name); err == nil {
+		return fmt.Errorf("container %s already exists", opts.Name)
+	}
+	if opts.Volume != "" {
+		exists, err := s.backend.VolumeExists(ctx, opts.Volume)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			s.volumes = append(s.volumes, opts.Volume)
+		}
+	}
+	s.members = append(s.members, opts.Name)
+	return Provision(ctx, s.backend, engine)
+}
+
+// undo removes the containers, stored credentials, volumes and network the
+// setup created, newest first, and returns cause noting the cleanup
+func (s *topologySetup) undo(ctx context.Context, cause error) error {
+	if len(s.members) == 0 {
+		return cause
+	}
+	var failed []string
+	for i := len(s.members) - 1; i >= 0; i-- {
+		name := s.members[i]
+		if info, err := s.backend.InspectContainer(ctx, name); err == nil {
+			if info.State != nil && info.State.Running {
+				if err := s.backend.StopContainer(ctx, name); err != nil {
+					failed = append(failed, err.Error())
+				}
+			}
+			if err := s.backend.RemoveContainer(ctx, name); err != nil {
+				failed = append(failed, err.Error())
+			}
+		}
+		if _, err := ForgetCredentials(name); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	for _, volume := range s.volumes {
+		if exists, _ := s.backend.VolumeExists(ctx, volume); exists {
+			if err := s.backend.RemoveVolume(ctx, volume); err != nil {
+				failed = append(failed, err.Error())
+			}
+		}
+	}
+	if s.network != "" {
+		if exists, _ := s.backend.NetworkExists(ctx, s.network); exists {
+			if err := s.backend.RemoveNetwork(ctx, s.network); err != nil {
+				failed = append(failed, err.Error())
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w (cleanup failed: %s)", cause, strings.Join(failed, "; "))
+	}
+	return fmt.Errorf("%w (removed the %d member(s) started so far)", cause, len(s.members))
+}
+
+// topologyTimeout is how long a topology may take to settle, e.g. to elect
+// a primary, unless the ready timeout says otherwise
+const topologyTimeout = 60 * time.Second
+
+// topologyNetwork returns the network shared by the members of a topology:
+// the one chosen for the first container or a dedicated one named after it
+func topologyNetwork(opts *ContainerOptions) string {
+	if opts.Network != "" {
+		return opts.Network
+	}
+	return opts.Name + "-net"
+}
+
+// memberOptions derives the options of the index-th additional member of a
+// topology from the first container's. The name and volume get an index
+// suffix and a fixed host port counts upwards. Init scripts only run on the
+// first container, since the others receive its data.
+func memberOptions(opts ContainerOptions, index int, role string) ContainerOptions {
+	member := opts
+	member.Name = fmt.Sprintf("%s-%d", opts.Name, index)
+	if opts.Volume != "" {
+		member.Volume = fmt.Sprintf("%s-%d", opts.Volume, index)
+	}
+	if port, err := strconv.Atoi(opts.HostPort); err == nil {
+		member.HostPort = strconv.Itoa(port + index)
+	}
+	member.ContainerPort = ""
+	member.InitScripts = nil
+	member.Role = role
+	return member
+}
+
+// pinPort resolves the host port of engine and makes the database listen on
+// the same port inside the container. The name:port address members
+// advertise to each other then also works from the host once the container
+// names resolve to the bind address.
+func pinPort(ctx context.Context, backend Backend, engine Engine) error {
+	if err := ResolvePort(ctx, backend, engine); err != nil {
+		return err
+	}
+	opts := engine.Options()
+	opts.ContainerPort = opts.HostPort
+	return nil
+}
+
+// announcesAddress reports whether the members of engine's topologies hand
+// their name:port address to clients, which then need pinned ports and a
+// hosts entry to follow it from the host. Redis replicas are reported to
+// Sentinel clients this way.
+func announcesAddress(engine Engine) bool {
+	_, ok := engine.(*RedisConfig)
+	return ok
+}
+
+// memberAddress returns the name:port address of a member on the topology network
+func memberAddress(engine Engine) string {
+	return engine.Options().Name + ":" + ContainerPort(engine)
+}
+
+// hostsEntry returns the /etc/hosts line that makes the members' advertised
+// addresses reachable from the host
+func hostsEntry(members []Engine) string {
+	names := make([]string, 0, len(members))
+	for _, member := range members {
+		names = append(names, member.Options().Name)
+	}
+	ip := members[0].Options().BindIP
+	switch ip {
+	case "", "0.0.0.0":
+		ip = "127.0.0.1"
+	case "::":
+		ip = "::1"
+	}
+	return ip + " " + strings.Join(names, " ")
+}
+
+// topologyWait returns the timeout for a topology to settle
+func topologyWait(engine Engine) time.Duration {
+	if timeout := engine.Options().ReadyTimeout; timeout > 0 {
+		return timeout
+	}
+	return topologyTimeout
+}
+
+// waitFor runs command inside a container until it succeeds or the timeout
+// expires and returns its last output
+func waitFor(ctx context.Context, backend Backend, containerID, what string, timeout time.Duration, command Command) (string, error) {
+	fmt.Printf("Waiting for %s", what)
+	deadline := time.After(timeout)
+	tick := time.NewTicker(probeInterval)
+	defer tick.Stop()
+
+	for {
+		output, err := runCommand(ctx, backend, containerID, command)
+		if err == nil {
+			fmt.Println()
+			return output, nil
+		}
+		fmt.Print(".")
+
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return "", ctx.Err()
+		case <-deadline:
+			fmt.Println()
+			return "", fmt.Errorf("timeout after %s waiting for %s: %w", timeout, what, err)
+		case <-tick.C:
+		}
+	}
+}
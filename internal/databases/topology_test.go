@@ -0,0 +1,120 @@
+package databases
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"dockerdb/internal/secrets"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestTopologyCleanup(t *testing.T) {
+	tests := []struct {
+		name string
+		// blocker is the name of an unrelated container whose member fails
+		blocker   string
+		provision func(ctx context.Context, backend Backend) error
+		started   []string
+	}{
+		{
+			name:    "postgres replicas",
+			blocker: "pg-2",
+			provision: func(ctx context.Context, backend Backend) error {
+				engine := NewPostgresConfig()
+				engine.Password = "secret"
+				setTopologyOptions(&engine.ContainerOptions, "pg")
+				_, err := ProvisionReplicas(ctx, backend, engine, 2)
+				return err
+			},
+			started: []string{"pg", "pg-1"},
+		},
+		{
+			name:    "mysql replicas",
+			blocker: "mysql-1",
+			provision: func(ctx context.Context, backend Backend) error {
+				engine := NewMySQLConfig()
+				engine.RootPassword = "secret"
+				setTopologyOptions(&engine.ContainerOptions, "mysql")
+				_, err := ProvisionReplicas(ctx, backend, engine, 1)
+				return err
+			},
+			started: []string{"mysql"},
+		},
+		{
+			name:    "mongodb replica set",
+			blocker: "rs-2",
+			provision: func(ctx context.Context, backend Backend) error {
+				engine := NewMongoDBConfig()
+				setTopologyOptions(&engine.ContainerOptions, "rs")
+				_, err := ProvisionReplicaSet(ctx, backend, engine, 3)
+				return err
+			},
+			started: []string{"rs", "rs-1"},
+		},
+		{
+			name:    "redis sentinel",
+			blocker: "cache-sentinel-2",
+			provision: func(ctx context.Context, backend Backend) error {
+				engine := NewRedisConfig()
+				setTopologyOptions(&engine.ContainerOptions, "cache")
+				_, err := ProvisionSentinel(ctx, backend, engine, 1)
+				return err
+			},
+			started: []string{"cache", "cache-1", "cache-sentinel-1"},
+		},
+		{
+			name:    "redis cluster",
+			blocker: "cache-3",
+			provision: func(ctx context.Context, backend Backend) error {
+				engine := NewRedisConfig()
+				setTopologyOptions(&engine.ContainerOptions, "cache")
+				_, err := ProvisionCluster(ctx, backend, engine)
+				return err
+			},
+			started: []string{"cache", "cache-1", "cache-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storeDir := useTempStore(t)
+			ctx := context.Background()
+			backend := newFakeBackend()
+			if _, err := backend.CreateContainer(ctx, tt.blocker, &container.Config{}, &container.HostConfig{}, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			err := tt.provision(ctx, backend)
+			if err == nil || !strings.Contains(err.Error(), "removed the") {
+				t.Fatalf("error = %v, want the members started so far to be removed", err)
+			}
+			store := secrets.NewStore(storeDir)
+			for _, name := range tt.started {
+				if _, err := backend.container(name); err == nil {
+					t.Errorf("container %s was not removed", name)
+				}
+				if _, stored, _ := store.Get(name); stored {
+					t.Errorf("credentials of %s were not forgotten", name)
+				}
+			}
+			if len(backend.volumes) != 0 {
+				t.Errorf("volumes %v were not removed", backend.volumes)
+			}
+			if len(backend.networks) != 0 {
+				t.Errorf("networks %v were not removed", backend.networks)
+			}
+			if _, err := backend.container(tt.blocker); err != nil {
+				t.Errorf("unrelated container %s was removed", tt.blocker)
+			}
+		})
+	}
+}
+
+// setTopologyOptions names the first container of a topology and lets it
+// pick free ports
+func setTopologyOptions(opts *ContainerOptions, name string) {
+	opts.Name = name
+	opts.Volume = name + "_data"
+	opts.HostPort = AutoPort
+}
@@ -0,0 +1,203 @@
+package databases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+// Role is a preset of privileges given to an additional user
+type Role string
+
+// Role presets understood by every engine
+const (
+	RoleReadOnly  Role = "read-only"
+	RoleReadWrite Role = "read-write"
+	RoleAdmin     Role = "admin"
+)
+
+// ParseRole validates a role preset name
+func ParseRole(s string) (Role, error) {
+	switch role := Role(s); role {
+	case RoleReadOnly, RoleReadWrite, RoleAdmin:
+		return role, nil
+	}
+	return "", fmt.Errorf("unknown role %q (expected %s, %s or %s)", s, RoleReadOnly, RoleReadWrite, RoleAdmin)
+}
+
+// User describes an additional database user
+type User struct {
+	Name     string
+	Password string
+	Role     Role
+	// Database the role applies to; empty means the engine's own database
+	Database string
+}
+
+// UserManager is implemented by engines that can manage additional users
+type UserManager interface {
+	// AddUserCommand returns the command creating a user with its role.
+	AddUserCommand(user User) (Command, error)
+	// RemoveUserCommand returns the command dropping a user. Database
+	// selects where the user is defined for engines that scope users.
+	RemoveUserCommand(name, database string) (Command, error)
+	// ListUsersCommand returns a command printing one user per line.
+	ListUsersCommand() Command
+}
+
+// DatabaseManager is implemented by engines that can manage additional
+// databases
+type DatabaseManager interface {
+	// CreateDatabaseCommand returns the command creating a database.
+	CreateDatabaseCommand(name string) (Command, error)
+	// DropDatabaseCommand returns the command dropping a database.
+	DropDatabaseCommand(name string) (Command, error)
+	// ListDatabasesCommand returns a command printing one database per line.
+	ListDatabasesCommand() Command
+}
+
+// runningEngine returns the engine and details of a running dockerdb container
+func runningEngine(ctx context.Context, backend Backend, name string) (Engine, types.ContainerJSON, error) {
+	info, err := Inspect(ctx, backend, name)
+	if err != nil {
+		return nil, info, err
+	}
+	if info.State == nil || !info.State.Running {
+		return nil, info, fmt.Errorf("%s is not running", name)
+	}
+	engine, err := FromContainer(info)
+	return engine, info, err
+}
+
+// userManager returns the user management of a running container
+func userManager(ctx context.Context, backend Backend, name string) (UserManager, string, error) {
+	engine, info, err := runningEngine(ctx, backend, name)
+	if err != nil {
+		return nil, "", err
+	}
+	manager, ok := engine.(UserManager)
+	if !ok {
+		return nil, "", fmt.Errorf("%s does not support managing users", engine.DisplayName())
+	}
+	return manager, info.ID, nil
+}
+
+// databaseManager returns the database management of a running container
+func databaseManager(ctx context.Context, backend Backend, name string) (DatabaseManager, string, error) {
+	engine, info, err := runningEngine(ctx, backend, name)
+	if err != nil {
+		return nil, "", err
+	}
+	manager, ok := engine.(DatabaseManager)
+	if !ok {
+		return nil, "", fmt.Errorf("%s does not support managing databases", engine.DisplayName())
+	}
+	return manager, info.ID, nil
+}
+
+// AddUser creates an additional user inside the named container
+func AddUser(ctx context.Context, backend Backend, name string, user User) error {
+	manager, id, err := userManager(ctx, backend, name)
+	if err != nil {
+		return err
+	}
+	command, err := manager.AddUserCommand(user)
+	if err != nil {
+		return err
+	}
+	if _, err := runCommand(ctx, backend, id, command); err != nil {
+		return err
+	}
+	return saveUsers(ctx, backend, manager, id)
+}
+
+// RemoveUser drops a user inside the named container
+func RemoveUser(ctx context.Context, backend Backend, name, user, database string) error {
+	manager, id, err := userManager(ctx, backend, name)
+	if err != nil {
+		return err
+	}
+	command, err := manager.RemoveUserCommand(user, database)
+	if err != nil {
+		return err
+	}
+	if _, err := runCommand(ctx, backend, id, command); err != nil {
+		return err
+	}
+	return saveUsers(ctx, backend, manager, id)
+}
+
+// saveUsers persists users changed at runtime where the database does not do
+// so itself, which is only the case for Redis ACL users
+func saveUsers(ctx context.Context, backend Backend, manager UserManager, containerID string) error {
+	redis, ok := manager.(*RedisConfig)
+	if !ok {
+		return nil
+	}
+	if !redis.usesACLFile() {
+		fmt.Printf("Warning: %s has no ACL file; the change is lost when it restarts (create it with --acl-user to keep users)\n", redis.Name)
+		return nil
+	}
+	return redis.saveACL(ctx, backend, containerID)
+}
+
+// ListUsers returns the users defined inside the named container
+func ListUsers(ctx context.Context, backend Backend, name string) ([]string, error) {
+	manager, id, err := userManager(ctx, backend, name)
+	if err != nil {
+		return nil, err
+	}
+	output, err := runCommand(ctx, backend, id, manager.ListUsersCommand())
+	return lines(output), err
+}
+
+// CreateDatabase creates an additional database inside the named container
+func CreateDatabase(ctx context.Context, backend Backend, name, database string) error {
+	manager, id, err := databaseManager(ctx, backend, name)
+	if err != nil {
+		return err
+	}
+	command, err := manager.CreateDatabaseCommand(database)
+	if err != nil {
+		return err
+	}
+	_, err = runCommand(ctx, backend, id, command)
+	return err
+}
+
+// DropDatabase drops a database inside the named container
+func DropDatabase(ctx context.Context, backend Backend, name, database string) error {
+	manager, id, err := databaseManager(ctx, backend, name)
+	if err != nil {
+		return err
+	}
+	command, err := manager.DropDatabaseCommand(database)
+	if err != nil {
+		return err
+	}
+	_, err = runCommand(ctx, backend, id, command)
+	return err
+}
+
+// ListDatabases returns the databases inside the named container
+func ListDatabases(ctx context.Context, backend Backend, name string) ([]string, error) {
+	manager, id, err := databaseManager(ctx, backend, name)
+	if err != nil {
+		return nil, err
+	}
+	output, err := runCommand(ctx, backend, id, manager.ListDatabasesCommand())
+	return lines(output), err
+}
+
+// lines splits command output into its non-empty lines
+func lines(output string) []string {
+	var result []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
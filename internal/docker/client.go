@@ -1,63 +1,326 @@
 package docker
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"os/exec"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 )
 
-// DockerClient is a struct that holds the Docker client configuration.
-type DockerClient struct{}
+// DockerClient wraps the Docker Engine API client used by dockerdb.
+type DockerClient struct {
+	api *client.Client
+}
 
-// NewDockerClient creates a new instance of DockerClient.
-func NewDockerClient() *DockerClient {
-	return &DockerClient{}
+// NewDockerClient creates a new DockerClient configured from the environment.
+func NewDockerClient() (*DockerClient, error) {
+	api, err := client.NewClientWithOpts(client.FromEnv, client.WithVersion("1.40"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	return &DockerClient{api: api}, nil
 }
 
-// RunContainer runs a Docker container with the specified image and options.
-func (dc *DockerClient) RunContainer(image string, options []string) error {
-	cmd := exec.Command("docker", append([]string{"run"}, options...)...)
-	cmd.Args = append(cmd.Args, image)
+// Close releases the underlying API client.
+func (dc *DockerClient) Close() error {
+	return dc.api.Close()
+}
 
-	output, err := cmd.CombinedOutput()
+// PullImage pulls a Docker image if it doesn't exist locally.
+func (dc *DockerClient) PullImage(ctx context.Context, image string) error {
+	_, _, err := dc.api.ImageInspectWithRaw(ctx, image)
+	if err == nil {
+		return nil
+	}
+	if !client.IsErrNotFound(err) {
+		return fmt.Errorf("failed to inspect image: %w", err)
+	}
+
+	fmt.Printf("Pulling image: %s...\n", image)
+	reader, err := dc.api.ImagePull(ctx, image, types.ImagePullOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to run container: %v, output: %s", err, output)
+		return fmt.Errorf("failed to pull image: %w", err)
 	}
+	defer reader.Close()
 
+	// Wait for pull to complete
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return fmt.Errorf("error while pulling image: %w", err)
+	}
+	fmt.Printf("Successfully pulled image: %s\n", image)
 	return nil
 }
 
-// StopContainer stops a running Docker container by its name or ID.
-func (dc *DockerClient) StopContainer(containerID string) error {
-	cmd := exec.Command("docker", "stop", containerID)
+// CreateNetwork creates a Docker network unless one with the same name exists.
+func (dc *DockerClient) CreateNetwork(ctx context.Context, name string) error {
+	networks, err := dc.api.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list networks: %w", err)
+	}
+	for _, n := range networks {
+		if n.Name == name {
+			fmt.Printf("Network %s already exists\n", name)
+			return nil
+		}
+	}
+
+	fmt.Printf("Creating network: %s...\n", name)
+	if _, err := dc.api.NetworkCreate(ctx, name, types.NetworkCreate{}); err != nil {
+		return fmt.Errorf("failed to create network: %w", err)
+	}
+	fmt.Printf("Successfully created network: %s\n", name)
+	return nil
+}
 
-	output, err := cmd.CombinedOutput()
+// CreateContainer creates a container and returns its ID.
+func (dc *DockerClient) CreateContainer(ctx context.Context, name string, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig) (string, error) {
+	resp, err := dc.api.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, name)
 	if err != nil {
-		return fmt.Errorf("failed to stop container: %v, output: %s", err, output)
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+// StartContainer starts a created or stopped container by its name or ID.
+func (dc *DockerClient) StartContainer(ctx context.Context, containerID string) error {
+	if err := dc.api.ContainerStart(ctx, containerID, types.ContainerStartOptions{}); err != nil {
+		return fmt.Errorf("failed to start container: %w", err)
+	}
+	return nil
+}
+
+// StopContainer stops a running Docker container by its name or ID.
+func (dc *DockerClient) StopContainer(ctx context.Context, containerID string) error {
+	if err := dc.api.ContainerStop(ctx, containerID, nil); err != nil {
+		return fmt.Errorf("failed to stop container: %w", err)
 	}
+	return nil
+}
 
+// RestartContainer restarts a Docker container by its name or ID.
+func (dc *DockerClient) RestartContainer(ctx context.Context, containerID string) error {
+	if err := dc.api.ContainerRestart(ctx, containerID, nil); err != nil {
+		return fmt.Errorf("failed to restart container: %w", err)
+	}
 	return nil
 }
 
 // RemoveContainer removes a Docker container by its name or ID.
-func (dc *DockerClient) RemoveContainer(containerID string) error {
-	cmd := exec.Command("docker", "rm", containerID)
+func (dc *DockerClient) RemoveContainer(ctx context.Context, containerID string) error {
+	if err := dc.api.ContainerRemove(ctx, containerID, types.ContainerRemoveOptions{}); err != nil {
+		return fmt.Errorf("failed to remove container: %w", err)
+	}
+	return nil
+}
+
+// InspectContainer returns the low-level information of a container.
+func (dc *DockerClient) InspectContainer(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	return dc.api.ContainerInspect(ctx, containerID)
+}
+
+// CopyToContainer extracts a tar archive into dstPath inside a container.
+// The container does not need to be running.
+func (dc *DockerClient) CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader) error {
+	if err := dc.api.CopyToContainer(ctx, containerID, dstPath, content, types.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("failed to copy to container: %w", err)
+	}
+	return nil
+}
+
+// Logs returns the last lines of a container's combined stdout and stderr.
+func (dc *DockerClient) Logs(ctx context.Context, containerID string, lines int) (string, error) {
+	reader, err := dc.api.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       strconv.Itoa(lines),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read container logs: %w", err)
+	}
+	defer reader.Close()
+
+	var logs bytes.Buffer
+	if _, err := stdcopy.StdCopy(&logs, &logs, reader); err != nil {
+		return "", fmt.Errorf("failed to read container logs: %w", err)
+	}
+	return logs.String(), nil
+}
+
+// ExecOptions configures a command executed inside a container.
+type ExecOptions struct {
+	Cmd    []string
+	Env    []string
+	User   string
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	Tty    bool
+	// Width and Height set the initial terminal size when Tty is enabled
+	Width  uint
+	Height uint
+}
+
+// Exec runs a command inside a running container, streaming its input and
+// output, and returns the command's exit code.
+func (dc *DockerClient) Exec(ctx context.Context, containerID string, opts ExecOptions) (int, error) {
+	created, err := dc.api.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		Cmd:          opts.Cmd,
+		Env:          opts.Env,
+		User:         opts.User,
+		Tty:          opts.Tty,
+		AttachStdin:  opts.Stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return -1, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	resp, err := dc.api.ContainerExecAttach(ctx, created.ID, types.ExecStartCheck{Tty: opts.Tty})
+	if err != nil {
+		return -1, fmt.Errorf("failed to attach to exec: %w", err)
+	}
+	defer resp.Close()
+
+	if opts.Tty && opts.Width > 0 && opts.Height > 0 {
+		// Resizing is best effort; the session works at the default size too
+		dc.api.ContainerExecResize(ctx, created.ID, types.ResizeOptions{Width: opts.Width, Height: opts.Height})
+	}
+
+	if opts.Stdin != nil {
+		go func() {
+			io.Copy(resp.Conn, opts.Stdin)
+			resp.CloseWrite()
+		}()
+	}
+
+	stdout, stderr := opts.Stdout, opts.Stderr
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+	if opts.Tty {
+		_, err = io.Copy(stdout, resp.Reader)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, resp.Reader)
+	}
+	if err != nil {
+		return -1, fmt.Errorf("failed to read exec output: %w", err)
+	}
+
+	// The stream closes when the process exits, but the daemon may need a
+	// moment before it reports the exit code
+	for {
+		inspect, err := dc.api.ContainerExecInspect(ctx, created.ID)
+		if err != nil {
+			return -1, fmt.Errorf("failed to inspect exec: %w", err)
+		}
+		if !inspect.Running {
+			return inspect.ExitCode, nil
+		}
+		select {
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// IsNotFound reports whether err indicates a missing container, image or network.
+func IsNotFound(err error) bool {
+	return client.IsErrNotFound(err)
+}
 
-	output, err := cmd.CombinedOutput()
+// ListContainers returns all containers, running or not, matching args.
+func (dc *DockerClient) ListContainers(ctx context.Context, args filters.Args) ([]types.Container, error) {
+	containers, err := dc.api.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: args})
 	if err != nil {
-		return fmt.Errorf("failed to remove container: %v, output: %s", err, output)
+		return nil, fmt.Errorf("failed to list containers: %w", err)
 	}
+	return containers, nil
+}
+
+// WaitContainer blocks until a container stops and returns its exit code.
+func (dc *DockerClient) WaitContainer(ctx context.Context, containerID string) (int64, error) {
+	statusCh, errCh := dc.api.ContainerWait(ctx, containerID, container.WaitConditionNotRunning)
+	select {
+	case status := <-statusCh:
+		return status.StatusCode, nil
+	case err := <-errCh:
+		return -1, fmt.Errorf("failed to wait for container: %w", err)
+	}
+}
 
+// CreateVolume creates a Docker volume with the given labels.
+func (dc *DockerClient) CreateVolume(ctx context.Context, name string, labels map[string]string) error {
+	if _, err := dc.api.VolumeCreate(ctx, volume.VolumeCreateBody{Name: name, Labels: labels}); err != nil {
+		return fmt.Errorf("failed to create volume: %w", err)
+	}
 	return nil
 }
 
-// PullImage pulls a Docker image from the Docker registry.
-func (dc *DockerClient) PullImage(image string) error {
-	cmd := exec.Command("docker", "pull", image)
+// InspectVolume returns the details of a Docker volume.
+func (dc *DockerClient) InspectVolume(ctx context.Context, name string) (types.Volume, error) {
+	return dc.api.VolumeInspect(ctx, name)
+}
+
+// ListVolumes returns the Docker volumes matching args.
+func (dc *DockerClient) ListVolumes(ctx context.Context, args filters.Args) ([]*types.Volume, error) {
+	body, err := dc.api.VolumeList(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+	return body.Volumes, nil
+}
+
+// VolumeExists reports whether a Docker volume with the given name exists.
+func (dc *DockerClient) VolumeExists(ctx context.Context, name string) (bool, error) {
+	_, err := dc.api.VolumeInspect(ctx, name)
+	if client.IsErrNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect volume: %w", err)
+	}
+	return true, nil
+}
 
-	output, err := cmd.CombinedOutput()
+// RemoveVolume removes a Docker volume by its name.
+func (dc *DockerClient) RemoveVolume(ctx context.Context, name string) error {
+	if err := dc.api.VolumeRemove(ctx, name, false); err != nil {
+		return fmt.Errorf("failed to remove volume: %w", err)
+	}
+	return nil
+}
+
+// NetworkExists reports whether a Docker network with the given name exists.
+func (dc *DockerClient) NetworkExists(ctx context.Context, name string) (bool, error) {
+	_, err := dc.api.NetworkInspect(ctx, name, types.NetworkInspectOptions{})
+	if client.IsErrNotFound(err) {
+		return false, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to pull image: %v, output: %s", err, output)
+		return false, fmt.Errorf("failed to inspect network: %w", err)
 	}
+	return true, nil
+}
 
+// RemoveNetwork removes a Docker network by its name or ID.
+func (dc *DockerClient) RemoveNetwork(ctx context.Context, name string) error {
+	if err := dc.api.NetworkRemove(ctx, name); err != nil {
+		return fmt.Errorf("failed to remove network: %w", err)
+	}
 	return nil
 }
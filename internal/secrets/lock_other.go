@@ -0,0 +1,11 @@
+//go:build !unix
+
+package secrets
+
+import "os"
+
+// lockFile does nothing. Locking the store is only implemented on Unix
+// systems; elsewhere concurrent writers may still lose updates.
+func lockFile(f *os.File) error {
+	return nil
+}
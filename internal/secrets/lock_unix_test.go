@@ -0,0 +1,35 @@
+//go:build unix
+
+package secrets
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestStoreConcurrentPut(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			// Separate stores, like separate dockerdb processes
+			if err := NewStore(dir).Put(name, Credentials{Engine: "redis", Password: name}); err != nil {
+				t.Error(err)
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	got, err := NewStore(dir).Names()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, names) {
+		t.Errorf("Names() = %v, want %v", got, names)
+	}
+}
@@ -0,0 +1,273 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// File names inside the store directory
+const (
+	dataFile = "credentials.enc"
+	keyFile  = "credentials.key"
+	lockName = "credentials.lock"
+)
+
+// keySize selects AES-256
+const keySize = 32
+
+// Credentials are the secrets of a single container
+type Credentials struct {
+	Engine       string    `json:"engine"`
+	User         string    `json:"user,omitempty"`
+	Password     string    `json:"password,omitempty"`
+	RootPassword string    `json:"root_password,omitempty"`
+	Updated      time.Time `json:"updated"`
+}
+
+// Store is an AES-GCM encrypted file of credentials keyed by container name.
+// The key lives in a separate file readable only by the current user.
+type Store struct {
+	dir string
+}
+
+// DefaultDir returns $XDG_CONFIG_HOME/dockerdb, or the platform's user
+// configuration directory when XDG_CONFIG_HOME is not set
+func DefaultDir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the configuration directory: %w", err)
+	}
+	return filepath.Join(base, "dockerdb"), nil
+}
+
+// Open returns the store in the default directory
+func Open() (*Store, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(dir), nil
+}
+
+// NewStore returns a store kept in dir. Nothing is created until the first
+// credentials are saved.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory holding the store's files
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Get returns the credentials stored for the named container
+func (s *Store) Get(name string) (Credentials, bool, error) {
+	all, err := s.load()
+	if err != nil {
+		return Credentials{}, false, err
+	}
+	creds, ok := all[name]
+	return creds, ok, nil
+}
+
+// Names returns the containers with stored credentials in alphabetical order
+func (s *Store) Names() ([]string, error) {
+	all, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Put stores the credentials of the named container, replacing any
+// previous ones
+func (s *Store) Put(name string, creds Credentials) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+	creds.Updated = time.Now().UTC()
+	all[name] = creds
+	return s.save(all)
+}
+
+// Delete forgets the credentials of the named container and reports whether
+// there were any
+func (s *Store) Delete(name string) (bool, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	all, err := s.load()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := all[name]; !ok {
+		return false, nil
+	}
+	delete(all, name)
+	return true, s.save(all)
+}
+
+// lock serializes changes to the store between dockerdb processes, which
+// would otherwise overwrite each other's updates. It returns the function
+// releasing the lock.
+func (s *Store) lock() (func(), error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", s.dir, err)
+	}
+	f, err := os.OpenFile(filepath.Join(s.dir, lockName), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open credential store lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	// Closing the file releases the lock
+	return func() { f.Close() }, nil
+}
+
+// load decrypts the whole store; a missing file is an empty store
+func (s *Store) load() (map[string]Credentials, error) {
+	all := make(map[string]Credentials)
+	sealed, err := os.ReadFile(filepath.Join(s.dir, dataFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credential store: %w", err)
+	}
+
+	aead, err := s.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("credential store %s is corrupt", s.dir)
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credential store %s: %w", s.dir, err)
+	}
+	if err := json.Unmarshal(plaintext, &all); err != nil {
+		return nil, fmt.Errorf("credential store %s is corrupt: %w", s.dir, err)
+	}
+	return all, nil
+}
+
+// save encrypts and atomically replaces the store
+func (s *Store) save(all map[string]Credentials) error {
+	plaintext, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	aead, err := s.cipher(true)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to encrypt credential store: %w", err)
+	}
+	return writeFile(filepath.Join(s.dir, dataFile), aead.Seal(nonce, nonce, plaintext, nil))
+}
+
+// cipher returns the AES-GCM cipher of the store, creating the key on first
+// use when create is set
+func (s *Store) cipher(create bool) (cipher.AEAD, error) {
+	path := filepath.Join(s.dir, keyFile)
+	key, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && create {
+		key, err = createKey(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credential store key: %w", err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("credential store key %s is invalid", path)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// createKey generates the key at path. The file is created exclusively, so
+// a process racing to create it too keeps the key that was written first.
+func createKey(path string) ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("failed to generate credential store key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(key); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return key, nil
+}
+
+// writeFile writes data readable only by the current user, replacing path
+// atomically so an interrupted write never loses the store
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "dockerdb"))
+	app := Credentials{Engine: "postgres", User: "app", Password: "secret"}
+	cache := Credentials{Engine: "redis", Password: "other"}
+
+	steps := []struct {
+		name   string
+		run    func() error
+		get    string
+		want   *Credentials
+		wantOK bool
+	}{
+		{name: "empty store", get: "app-db"},
+		{name: "put", run: func() error { return store.Put("app-db", app) }, get: "app-db", want: &app, wantOK: true},
+		{name: "put another", run: func() error { return store.Put("cache", cache) }, get: "cache", want: &cache, wantOK: true},
+		{name: "replace", run: func() error {
+			app.Password = "rotated"
+			return store.Put("app-db", app)
+		}, get: "app-db", want: &app, wantOK: true},
+		{name: "delete", run: func() error {
+			deleted, err := store.Delete("app-db")
+			if err == nil && !deleted {
+				t.Error("Delete() = false for stored credentials")
+			}
+			return err
+		}, get: "app-db"},
+		{name: "delete again", run: func() error {
+			deleted, err := store.Delete("app-db")
+			if deleted {
+				t.Error("Delete() = true for forgotten credentials")
+			}
+			return err
+		}, get: "cache", want: &cache, wantOK: true},
+	}
+	for _, step := range steps {
+		if step.run != nil {
+			if err := step.run(); err != nil {
+				t.Fatalf("%s: %v", step.name, err)
+			}
+		}
+		// A second store on the same directory reads what the first wrote
+		got, ok, err := NewStore(store.Dir()).Get(step.get)
+		if err != nil {
+			t.Fatalf("%s: Get(%q) error = %v", step.name, step.get, err)
+		}
+		if ok != step.wantOK {
+			t.Fatalf("%s: Get(%q) ok = %v, want %v", step.name, step.get, ok, step.wantOK)
+		}
+		if !ok {
+			continue
+		}
+		if got.Updated.IsZero() {
+			t.Errorf("%s: Updated is not set", step.name)
+		}
+		got.Updated = step.want.Updated
+		if !reflect.DeepEqual(got, *step.want) {
+			t.Errorf("%s: Get(%q) = %+v, want %+v", step.name, step.get, got, *step.want)
+		}
+	}
+
+	names, err := store.Names()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"cache"}) {
+		t.Errorf("Names() = %v, want [cache]", names)
+	}
+	for _, name := range []string{dataFile, keyFile} {
+		info, err := os.Stat(filepath.Join(store.Dir(), name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != 0o600 {
+			t.Errorf("%s has mode %o, want 600", name, mode)
+		}
+	}
+}
+
+func TestStoreErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(dir string) error
+	}{
+		{name: "invalid key", setup: func(dir string) error {
+			if err := NewStore(dir).Put("app-db", Credentials{Engine: "mysql"}); err != nil {
+				return err
+			}
+			return os.WriteFile(filepath.Join(dir, keyFile), []byte("short"), 0o600)
+		}},
+		{name: "other key", setup: func(dir string) error {
+			if err := NewStore(dir).Put("app-db", Credentials{Engine: "mysql"}); err != nil {
+				return err
+			}
+			return os.WriteFile(filepath.Join(dir, keyFile), make([]byte, keySize), 0o600)
+		}},
+		{name: "truncated data", setup: func(dir string) error {
+			if err := NewStore(dir).Put("app-db", Credentials{Engine: "mysql"}); err != nil {
+				return err
+			}
+			return os.WriteFile(filepath.Join(dir, dataFile), []byte{1, 2, 3}, 0o600)
+		}},
+		{name: "data without key", setup: func(dir string) error {
+			return os.WriteFile(filepath.Join(dir, dataFile), []byte("sealed"), 0o600)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := tt.setup(dir); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := NewStore(dir).Get("app-db"); err == nil {
+				t.Error("Get() succeeded on a damaged store")
+			}
+		})
+	}
+}
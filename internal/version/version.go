@@ -0,0 +1,5 @@
+package version
+
+// Version is the dockerdb release, overridden at build time with
+// -ldflags "-X dockerdb/internal/version.Version=v1.2.3"
+var Version = "dev"